@@ -1,41 +1,63 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/coupon"
+	"github.com/mercadolibre/backend-challenge/internal/basket/formatter"
 	localMap "github.com/mercadolibre/backend-challenge/internal/basket/local-map"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
 	localLib "github.com/mercadolibre/backend-challenge/local-library"
 )
 
 const (
 	bktIDParam              = "basket_id"
+	quantityQueryParam      = "quantity"
+	localeQueryParam        = "locale"
 	bktNotFoundMsg          = "basket not found"
 	bktIDRequiredMsg        = "basket_id is required"
 	bktInternalServerErrMsg = "internal server error"
+	versionMismatchMsg      = "the basket changed, get it again and retry"
+	ifMatchHeader           = "If-Match"
+	eTagHeader              = "ETag"
 )
 
 // A BktService interface is used to manage the Basket methods.
 type BktService interface {
 	Create() basket.Basket
 	Get(bktID string) (basket.Basket, error)
-	GetAmount(bktID string) (float64, error)
-	Delete(bktID string) error
-	AddProduct(bktID string, prdID string, quantity int) (basket.Basket, error)
+	GetAmount(bktID string) (basket.GetAmount, error)
+	Delete(bktID string, version int) error
+	AddProduct(bktID string, prdID string, quantity int, version int) (basket.Basket, error)
+	RemoveProduct(bktID string, prdID string, quantity int, version int) (basket.Basket, error)
+	SetProductQuantity(bktID string, prdID string, quantity int, version int) (basket.Basket, error)
+	ReplaceProducts(bktID string, products map[string]int, version int) (basket.Basket, error)
+	ApplyCoupon(bktID string, code string, version int) (basket.Basket, error)
+	RemoveCoupon(bktID string, version int) (basket.Basket, error)
+	Checkout(bktID string, version int) (basket.Basket, error)
+	Pay(bktID string, version int) (basket.Basket, error)
+	Quote(items []basket.QuoteItem, promotions []promotion.Definition, at time.Time) (basket.Quote, error)
 }
 
 // BktHandler is responsible for handle methods related to basket service.
 type BktHandler struct {
 	bktService BktService
+	formatter  *formatter.MoneyFormatter
 }
 
 // New return an instance of BktHandler.
 func New(bktService BktService) BktHandler {
 	return BktHandler{
 		bktService: bktService,
+		formatter:  formatter.New(),
 	}
 }
 
@@ -44,7 +66,10 @@ func (rh *BktHandler) CreateBkt(w http.ResponseWriter, r *http.Request) {
 	if !isValidCaller(w, r) {
 		return
 	}
-	localLib.RespondJSON(w, rh.bktService.Create(), http.StatusCreated)
+	bkt := rh.bktService.Create()
+	bkt.FormattedAmount = rh.formatMoney(r, bkt.Amount)
+	setETag(w, bkt.Version)
+	localLib.RespondJSON(w, bkt, http.StatusCreated)
 }
 
 // GetBkt returns the basket corresponding to the id sent by parameter.
@@ -70,6 +95,8 @@ func (rh *BktHandler) GetBkt(w http.ResponseWriter, r *http.Request) {
 		localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
 		return
 	}
+	bkt.FormattedAmount = rh.formatMoney(r, bkt.Amount)
+	setETag(w, bkt.Version)
 	localLib.RespondJSON(w, bkt, http.StatusOK)
 }
 
@@ -91,26 +118,193 @@ func (rh *BktHandler) AddProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bkt, err := rh.bktService.AddProduct(bktID, body.Code, body.Quantity)
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		return
+	}
+
+	bkt, err := rh.bktService.AddProduct(bktID, body.Code, body.Quantity, version)
 	// Not found is not implemented as giving an error here means that we have not previously loaded the product.
 	if err != nil {
 		if err == localMap.ErrBktNotFound {
 			localLib.RespondJSON(w, localLib.Error{Message: bktNotFoundMsg, StatusCode: http.StatusNotFound}, http.StatusNotFound)
 			return
 		}
+		if err == localMap.ErrVersionMismatch {
+			localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+			return
+		}
+		if err == localMap.ErrBasketNotOpen {
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+			return
+		}
 		if err == localMap.ErrInvalidProductCode {
 			localLib.RespondJSON(w, localLib.Error{Message: "invalid product code", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, localMap.ErrInvalidQuantity) {
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+			return
+		}
+		if err == localMap.ErrVariantRequired {
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, localMap.ErrOutOfStock) {
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+			return
+		}
 		// TODO add metrics
 		log.Printf("error in add product: %s", err.Error())
 		localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
 		return
 	}
 
+	bkt.FormattedAmount = rh.formatMoney(r, bkt.Amount)
+	setETag(w, bkt.Version)
+	localLib.RespondJSON(w, bkt, http.StatusOK)
+}
+
+// RemoveProduct takes the units of the quantity query parameter of a product out of the basket, all of them without it.
+func (rh *BktHandler) RemoveProduct(w http.ResponseWriter, r *http.Request) {
+	if !isValidCaller(w, r) {
+		return
+	}
+	bktID := chi.URLParam(r, bktIDParam)
+	if bktID == "" {
+		localLib.RespondJSON(w, localLib.Error{Message: bktIDRequiredMsg, StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	quantity := 0
+	if value := r.URL.Query().Get(quantityQueryParam); value != "" {
+		var err error
+		if quantity, err = strconv.Atoi(value); err != nil || quantity <= 0 {
+			localLib.RespondJSON(w, localLib.Error{Message: localMap.ErrInvalidQuantity.Error(), StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		return
+	}
+
+	bkt, err := rh.bktService.RemoveProduct(bktID, chi.URLParam(r, productCodeParam), quantity, version)
+	if err != nil {
+		switch {
+		case err == localMap.ErrBktNotFound:
+			localLib.RespondJSON(w, localLib.Error{Message: bktNotFoundMsg, StatusCode: http.StatusNotFound}, http.StatusNotFound)
+		case err == localMap.ErrVersionMismatch:
+			localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		case err == localMap.ErrBasketNotOpen:
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+		case err == localMap.ErrProductNotInBasket:
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusNotFound}, http.StatusNotFound)
+		case errors.Is(err, localMap.ErrInvalidQuantity):
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		default:
+			// TODO add metrics
+			log.Printf("error in remove product: %s", err.Error())
+			localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	bkt.FormattedAmount = rh.formatMoney(r, bkt.Amount)
+	setETag(w, bkt.Version)
+	localLib.RespondJSON(w, bkt, http.StatusOK)
+}
+
+// SetProductQuantity sets the units of a product in the basket, retrying the request leaves the same basket.
+func (rh *BktHandler) SetProductQuantity(w http.ResponseWriter, r *http.Request) {
+	if !isValidCaller(w, r) {
+		return
+	}
+	bktID := chi.URLParam(r, bktIDParam)
+	if bktID == "" {
+		localLib.RespondJSON(w, localLib.Error{Message: bktIDRequiredMsg, StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	var body basket.SetQuantity
+	if err := localLib.Bind(r, &body); err != nil || body.Quantity == nil {
+		localLib.RespondJSON(w, localLib.Error{Message: "invalid body", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		return
+	}
+
+	bkt, err := rh.bktService.SetProductQuantity(bktID, chi.URLParam(r, productCodeParam), *body.Quantity, version)
+	if err != nil {
+		rh.respondUpdateProductsError(w, err)
+		return
+	}
+	bkt.FormattedAmount = rh.formatMoney(r, bkt.Amount)
+	setETag(w, bkt.Version)
+	localLib.RespondJSON(w, bkt, http.StatusOK)
+}
+
+// ReplaceProducts replaces the contents of the basket, retrying the request leaves the same basket.
+func (rh *BktHandler) ReplaceProducts(w http.ResponseWriter, r *http.Request) {
+	if !isValidCaller(w, r) {
+		return
+	}
+	bktID := chi.URLParam(r, bktIDParam)
+	if bktID == "" {
+		localLib.RespondJSON(w, localLib.Error{Message: bktIDRequiredMsg, StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	var body basket.ReplaceProducts
+	if err := localLib.Bind(r, &body); err != nil || body.Products == nil {
+		localLib.RespondJSON(w, localLib.Error{Message: "invalid body", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		return
+	}
+
+	bkt, err := rh.bktService.ReplaceProducts(bktID, body.Products, version)
+	if err != nil {
+		rh.respondUpdateProductsError(w, err)
+		return
+	}
+	bkt.FormattedAmount = rh.formatMoney(r, bkt.Amount)
+	setETag(w, bkt.Version)
 	localLib.RespondJSON(w, bkt, http.StatusOK)
 }
 
+func (rh *BktHandler) respondUpdateProductsError(w http.ResponseWriter, err error) {
+	switch {
+	case err == localMap.ErrBktNotFound:
+		localLib.RespondJSON(w, localLib.Error{Message: bktNotFoundMsg, StatusCode: http.StatusNotFound}, http.StatusNotFound)
+	case err == localMap.ErrVersionMismatch:
+		localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+	case err == localMap.ErrBasketNotOpen:
+		localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+	case err == localMap.ErrInvalidProductCode:
+		localLib.RespondJSON(w, localLib.Error{Message: "invalid product code", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+	case errors.Is(err, localMap.ErrInvalidQuantity), err == localMap.ErrVariantRequired:
+		localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+	case errors.Is(err, localMap.ErrOutOfStock):
+		localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+	default:
+		// TODO add metrics
+		log.Printf("error in update products: %s", err.Error())
+		localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
+	}
+}
+
 func isValidCaller(w http.ResponseWriter, r *http.Request) bool {
 	callerScope := r.Header.Get("x-client-key")
 	secretCaller := os.Getenv("X_CLIENT_KEY")
@@ -145,8 +339,101 @@ func (rh *BktHandler) GetAmount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	localLib.RespondJSON(w, basket.GetAmount{BktID: bktID, Amount: amount}, http.StatusOK)
+	amount.FormattedAmount = rh.formatMoney(r, amount.Amount)
+	setETag(w, amount.Version)
+	localLib.RespondJSON(w, amount, http.StatusOK)
+}
 
+// ApplyCoupon applies the coupon code sent in the body to the basket.
+func (rh *BktHandler) ApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	if !isValidCaller(w, r) {
+		return
+	}
+	bktID := chi.URLParam(r, bktIDParam)
+	if bktID == "" {
+		localLib.RespondJSON(w, localLib.Error{Message: bktIDRequiredMsg, StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	var body basket.ApplyCoupon
+	if err := localLib.Bind(r, &body); err != nil || body.Code == "" {
+		localLib.RespondJSON(w, localLib.Error{Message: "invalid body", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		return
+	}
+
+	bkt, err := rh.bktService.ApplyCoupon(bktID, body.Code, version)
+	if err != nil {
+		switch err {
+		case localMap.ErrBktNotFound:
+			localLib.RespondJSON(w, localLib.Error{Message: bktNotFoundMsg, StatusCode: http.StatusNotFound}, http.StatusNotFound)
+		case localMap.ErrVersionMismatch:
+			localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		case localMap.ErrBasketNotOpen:
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+		case coupon.ErrCouponNotFound:
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusNotFound}, http.StatusNotFound)
+		case localMap.ErrCouponAlreadyApplied:
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+		case coupon.ErrCouponExpired, coupon.ErrCouponExhausted, coupon.ErrMinAmountNotReached:
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusUnprocessableEntity}, http.StatusUnprocessableEntity)
+		default:
+			// TODO add metrics
+			log.Printf("error in apply coupon: %s", err.Error())
+			localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	bkt.FormattedAmount = rh.formatMoney(r, bkt.Amount)
+	setETag(w, bkt.Version)
+	localLib.RespondJSON(w, bkt, http.StatusOK)
+}
+
+// RemoveCoupon removes the coupon of the basket.
+func (rh *BktHandler) RemoveCoupon(w http.ResponseWriter, r *http.Request) {
+	if !isValidCaller(w, r) {
+		return
+	}
+	bktID := chi.URLParam(r, bktIDParam)
+	if bktID == "" {
+		localLib.RespondJSON(w, localLib.Error{Message: bktIDRequiredMsg, StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		return
+	}
+
+	bkt, err := rh.bktService.RemoveCoupon(bktID, version)
+	if err != nil {
+		switch err {
+		case localMap.ErrBktNotFound:
+			localLib.RespondJSON(w, localLib.Error{Message: bktNotFoundMsg, StatusCode: http.StatusNotFound}, http.StatusNotFound)
+		case localMap.ErrVersionMismatch:
+			localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		case localMap.ErrBasketNotOpen:
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+		case localMap.ErrCouponNotApplied:
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusNotFound}, http.StatusNotFound)
+		default:
+			// TODO add metrics
+			log.Printf("error in remove coupon: %s", err.Error())
+			localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	bkt.FormattedAmount = rh.formatMoney(r, bkt.Amount)
+	setETag(w, bkt.Version)
+	localLib.RespondJSON(w, bkt, http.StatusOK)
 }
 
 // RemoveBkt deletes the basket sent by parameter.
@@ -160,12 +447,26 @@ func (rh *BktHandler) RemoveBkt(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := rh.bktService.Delete(bktID)
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		return
+	}
+
+	err := rh.bktService.Delete(bktID, version)
 	if err != nil {
 		if err == localMap.ErrBktNotFound {
 			localLib.RespondJSON(w, localLib.Error{Message: bktNotFoundMsg, StatusCode: http.StatusNotFound}, http.StatusNotFound)
 			return
 		}
+		if err == localMap.ErrVersionMismatch {
+			localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+			return
+		}
+		if errors.Is(err, localMap.ErrIllegalTransition) {
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+			return
+		}
 		// TODO add metrics
 		log.Printf("error in delete basket: %s", err.Error())
 		localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
@@ -175,6 +476,88 @@ func (rh *BktHandler) RemoveBkt(w http.ResponseWriter, r *http.Request) {
 	localLib.RespondJSON(w, nil, http.StatusNoContent)
 }
 
+// Checkout freezes the contents and the price of the basket until it is paid.
+func (rh *BktHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	rh.changeStatus(w, r, rh.bktService.Checkout, "checkout")
+}
+
+// Pay marks the basket checking out as paid.
+func (rh *BktHandler) Pay(w http.ResponseWriter, r *http.Request) {
+	rh.changeStatus(w, r, rh.bktService.Pay, "pay")
+}
+
+// changeStatus moves the basket to another status with the change, illegal transitions answer 409.
+func (rh *BktHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(bktID string, version int) (basket.Basket, error), action string) {
+	if !isValidCaller(w, r) {
+		return
+	}
+	bktID := chi.URLParam(r, bktIDParam)
+	if bktID == "" {
+		localLib.RespondJSON(w, localLib.Error{Message: bktIDRequiredMsg, StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		return
+	}
+
+	bkt, err := change(bktID, version)
+	if err != nil {
+		switch {
+		case err == localMap.ErrBktNotFound:
+			localLib.RespondJSON(w, localLib.Error{Message: bktNotFoundMsg, StatusCode: http.StatusNotFound}, http.StatusNotFound)
+		case err == localMap.ErrVersionMismatch:
+			localLib.RespondJSON(w, localLib.Error{Message: versionMismatchMsg, StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed)
+		case err == localMap.ErrBasketEmpty, err == coupon.ErrCouponExhausted, errors.Is(err, localMap.ErrIllegalTransition):
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+		default:
+			// TODO add metrics
+			log.Printf("error in %s: %s", action, err.Error())
+			localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	bkt.FormattedAmount = rh.formatMoney(r, bkt.Amount)
+	setETag(w, bkt.Version)
+	localLib.RespondJSON(w, bkt, http.StatusOK)
+}
+
+// ifMatchVersion returns the basket version of the If-Match header, zero when it is missing or "*" so any version matches.
+// It returns false when the header is not the strong ETag of a basket version, which matches no basket.
+func ifMatchVersion(r *http.Request) (int, bool) {
+	value := strings.TrimSpace(r.Header.Get(ifMatchHeader))
+	if value == "" || value == "*" {
+		return 0, true
+	}
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, false
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// setETag sets the basket version as the ETag of the response, to be sent back in If-Match.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set(eTagHeader, strconv.Quote(strconv.Itoa(version)))
+}
+
+// formatMoney formats the amount with the locale of the "locale" query parameter if any,
+// otherwise with the preferred languages of the Accept-Language header.
+func (rh *BktHandler) formatMoney(r *http.Request, amount basket.Money) string {
+	locales := formatter.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if locale := r.URL.Query().Get(localeQueryParam); locale != "" {
+		locales = append([]string{locale}, locales...)
+	}
+	return rh.formatter.Format(amount, locales...)
+}
+
 // Ping is the endpoint to validate that the application was up correctly.
 func (rh *BktHandler) Ping(w http.ResponseWriter, _ *http.Request) {
 	localLib.RespondJSON(w, "pong", http.StatusOK)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -13,7 +14,9 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/coupon"
 	localMap "github.com/mercadolibre/backend-challenge/internal/basket/local-map"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
 	localLib "github.com/mercadolibre/backend-challenge/local-library"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -32,10 +35,17 @@ var (
 		Products: map[string]int{
 			"PEN": 1,
 		},
-		Amount:          5.0,
+		Amount:          basket.NewMoney(500, basket.DefaultCurrency),
 		DateCreated:     time.Now().String(),
 		DateLastUpdated: time.Now().String(),
 	}
+	amountOk = basket.GetAmount{
+		BktID:    "RANDOM123",
+		Subtotal: basket.NewMoney(1000, basket.DefaultCurrency),
+		Amount:   basket.NewMoney(1000, basket.DefaultCurrency),
+		Currency: basket.DefaultCurrency,
+		Version:  3,
+	}
 )
 
 type ServiceBktMock struct {
@@ -52,21 +62,61 @@ func (s *ServiceBktMock) Get(_ string) (basket.Basket, error) {
 	return args.Get(0).(basket.Basket), args.Error(1)
 }
 
-func (s *ServiceBktMock) GetAmount(_ string) (float64, error) {
+func (s *ServiceBktMock) GetAmount(_ string) (basket.GetAmount, error) {
 	args := s.Called()
-	return args.Get(0).(float64), args.Error(1)
+	return args.Get(0).(basket.GetAmount), args.Error(1)
 }
 
-func (s *ServiceBktMock) Delete(_ string) error {
+func (s *ServiceBktMock) Delete(_ string, _ int) error {
 	args := s.Called()
 	return args.Error(0)
 }
 
-func (s *ServiceBktMock) AddProduct(_ string, _ string, _ int) (basket.Basket, error) {
+func (s *ServiceBktMock) AddProduct(_ string, _ string, _ int, _ int) (basket.Basket, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Basket), args.Error(1)
+}
+
+func (s *ServiceBktMock) RemoveProduct(_ string, _ string, _ int, _ int) (basket.Basket, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Basket), args.Error(1)
+}
+
+func (s *ServiceBktMock) SetProductQuantity(_ string, _ string, _ int, _ int) (basket.Basket, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Basket), args.Error(1)
+}
+
+func (s *ServiceBktMock) ReplaceProducts(_ string, _ map[string]int, _ int) (basket.Basket, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Basket), args.Error(1)
+}
+
+func (s *ServiceBktMock) ApplyCoupon(_ string, _ string, _ int) (basket.Basket, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Basket), args.Error(1)
+}
+
+func (s *ServiceBktMock) RemoveCoupon(_ string, _ int) (basket.Basket, error) {
 	args := s.Called()
 	return args.Get(0).(basket.Basket), args.Error(1)
 }
 
+func (s *ServiceBktMock) Checkout(_ string, _ int) (basket.Basket, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Basket), args.Error(1)
+}
+
+func (s *ServiceBktMock) Pay(_ string, _ int) (basket.Basket, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Basket), args.Error(1)
+}
+
+func (s *ServiceBktMock) Quote(_ []basket.QuoteItem, _ []promotion.Definition, _ time.Time) (basket.Quote, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Quote), args.Error(1)
+}
+
 func Test_CreateBkt(t *testing.T) {
 	var tests = []struct {
 		name            string
@@ -143,7 +193,7 @@ func Test_GetAmount(t *testing.T) {
 			wantStatus: http.StatusOK,
 			mockBktServFunc: func() BktService {
 				mockTableUpdate := ServiceBktMock{}
-				mockTableUpdate.On("GetAmount", mock.Anything).Return(10.0, nil)
+				mockTableUpdate.On("GetAmount", mock.Anything).Return(amountOk, nil)
 				return &mockTableUpdate
 			},
 		},
@@ -169,7 +219,7 @@ func Test_GetAmount(t *testing.T) {
 			wantStatus: http.StatusNotFound,
 			mockBktServFunc: func() BktService {
 				mockTableUpdate := ServiceBktMock{}
-				mockTableUpdate.On("GetAmount", mock.Anything).Return(0.0, localMap.ErrBktNotFound)
+				mockTableUpdate.On("GetAmount", mock.Anything).Return(basket.GetAmount{}, localMap.ErrBktNotFound)
 				return &mockTableUpdate
 			},
 		},
@@ -178,7 +228,7 @@ func Test_GetAmount(t *testing.T) {
 			wantStatus: http.StatusInternalServerError,
 			mockBktServFunc: func() BktService {
 				mockTableUpdate := ServiceBktMock{}
-				mockTableUpdate.On("GetAmount", mock.Anything).Return(0.0, errors.New("random error"))
+				mockTableUpdate.On("GetAmount", mock.Anything).Return(basket.GetAmount{}, errors.New("random error"))
 				return &mockTableUpdate
 			},
 		},
@@ -209,6 +259,63 @@ func Test_GetAmount(t *testing.T) {
 
 			resp := rr.Result()
 			require.Equal(t, test.wantStatus, resp.StatusCode)
+			if test.wantStatus == http.StatusOK {
+				require.Equal(t, `"3"`, resp.Header.Get("ETag"))
+			}
+		})
+	}
+}
+
+func Test_GetAmountFormatted(t *testing.T) {
+	var tests = []struct {
+		name           string
+		query          string
+		acceptLanguage string
+		expected       string
+	}{
+		{
+			name:     "Get Amount formatted - Default locale",
+			expected: "€10.00",
+		},
+		{
+			name:           "Get Amount formatted - Accept-Language",
+			acceptLanguage: "de-DE,de;q=0.9,en;q=0.8",
+			expected:       "10,00\u00a0€",
+		},
+		{
+			name:           "Get Amount formatted - Query param wins",
+			query:          "?locale=pt-BR",
+			acceptLanguage: "de",
+			expected:       "€10,00",
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv(XClientKeyEnvVar, XClientKeyValue)
+			require.NoError(t, err)
+
+			mockBktService := ServiceBktMock{}
+			mockBktService.On("GetAmount", mock.Anything).Return(amountOk, nil)
+			bktHandler := New(&mockBktService)
+			r := chi.NewRouter()
+			r.Get("/basket/{basket_id}/amount", bktHandler.GetAmount)
+
+			rq := httptest.NewRequest(http.MethodGet, "/basket/"+bktCreated.ID+"/amount"+test.query, nil)
+			rq.Header.Set(XClientKey, XClientKeyValue)
+			rq.Header.Set("Accept-Language", test.acceptLanguage)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			resp := rr.Result()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var response basket.GetAmount
+			err = json.NewDecoder(resp.Body).Decode(&response)
+			require.NoError(t, err)
+			require.Equal(t, bktCreated.ID, response.BktID)
+			require.Equal(t, int64(1000), response.Amount.Amount)
+			require.Equal(t, test.expected, response.FormattedAmount)
 		})
 	}
 }
@@ -283,6 +390,26 @@ func Test_AddProduct(t *testing.T) {
 				return &mockTableUpdate
 			},
 		},
+		{
+			name:        "Add Product - BadRequest - Variant required",
+			wantStatus:  http.StatusBadRequest,
+			giveRequest: bytes.NewReader(productOk),
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("AddProduct", mock.Anything).Return(basket.Basket{}, localMap.ErrVariantRequired)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Add Product - Conflict - Out of stock",
+			wantStatus:  http.StatusConflict,
+			giveRequest: bytes.NewReader(productOk),
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("AddProduct", mock.Anything).Return(basket.Basket{}, fmt.Errorf("%w: \"PEN\": 0 units available", localMap.ErrOutOfStock))
+				return &mockTableUpdate
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -314,6 +441,175 @@ func Test_AddProduct(t *testing.T) {
 	}
 }
 
+func Test_ApplyCoupon(t *testing.T) {
+	couponOk, err := json.Marshal(basket.ApplyCoupon{Code: "LANA10"})
+	require.NoError(t, err)
+
+	var tests = []struct {
+		name            string
+		wantStatus      int
+		mockBktServFunc func() BktService
+		giveRequest     []byte
+	}{
+		{
+			name:        "Apply coupon - Ok",
+			wantStatus:  http.StatusOK,
+			giveRequest: couponOk,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("ApplyCoupon", mock.Anything).Return(bktCreated, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Apply coupon - Forbidden",
+			wantStatus:  http.StatusForbidden,
+			giveRequest: couponOk,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Apply coupon - BadRequest - Empty code",
+			wantStatus:  http.StatusBadRequest,
+			giveRequest: []byte(`{"code": ""}`),
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Apply coupon - Bkt not found",
+			wantStatus:  http.StatusNotFound,
+			giveRequest: couponOk,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("ApplyCoupon", mock.Anything).Return(basket.Basket{}, localMap.ErrBktNotFound)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Apply coupon - Coupon not found",
+			wantStatus:  http.StatusNotFound,
+			giveRequest: couponOk,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("ApplyCoupon", mock.Anything).Return(basket.Basket{}, coupon.ErrCouponNotFound)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Apply coupon - Conflict - Coupon already applied",
+			wantStatus:  http.StatusConflict,
+			giveRequest: couponOk,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("ApplyCoupon", mock.Anything).Return(basket.Basket{}, localMap.ErrCouponAlreadyApplied)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Apply coupon - Unprocessable entity - Expired",
+			wantStatus:  http.StatusUnprocessableEntity,
+			giveRequest: couponOk,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("ApplyCoupon", mock.Anything).Return(basket.Basket{}, coupon.ErrCouponExpired)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Apply coupon - Internal server error",
+			wantStatus:  http.StatusInternalServerError,
+			giveRequest: couponOk,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("ApplyCoupon", mock.Anything).Return(basket.Basket{}, errors.New("random error"))
+				return &mockTableUpdate
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv(XClientKeyEnvVar, XClientKeyValue)
+			require.NoError(t, err)
+
+			bktHandler := New(test.mockBktServFunc())
+			r := chi.NewRouter()
+			r.Put("/basket/{basket_id}/coupon", bktHandler.ApplyCoupon)
+
+			rq := httptest.NewRequest(http.MethodPut, "/basket/"+bktCreated.ID+"/coupon", bytes.NewReader(test.giveRequest))
+			if tt.wantStatus != http.StatusForbidden {
+				rq.Header.Set(XClientKey, XClientKeyValue)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			resp := rr.Result()
+			require.Equal(t, test.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func Test_RemoveCoupon(t *testing.T) {
+	var tests = []struct {
+		name            string
+		wantStatus      int
+		mockBktServFunc func() BktService
+	}{
+		{
+			name:       "Remove coupon - Ok",
+			wantStatus: http.StatusOK,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("RemoveCoupon", mock.Anything).Return(bktCreated, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Remove coupon - Bkt not found",
+			wantStatus: http.StatusNotFound,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("RemoveCoupon", mock.Anything).Return(basket.Basket{}, localMap.ErrBktNotFound)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Remove coupon - Coupon not applied",
+			wantStatus: http.StatusNotFound,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("RemoveCoupon", mock.Anything).Return(basket.Basket{}, localMap.ErrCouponNotApplied)
+				return &mockTableUpdate
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv(XClientKeyEnvVar, XClientKeyValue)
+			require.NoError(t, err)
+
+			bktHandler := New(test.mockBktServFunc())
+			r := chi.NewRouter()
+			r.Delete("/basket/{basket_id}/coupon", bktHandler.RemoveCoupon)
+
+			rq := httptest.NewRequest(http.MethodDelete, "/basket/"+bktCreated.ID+"/coupon", nil)
+			rq.Header.Set(XClientKey, XClientKeyValue)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			resp := rr.Result()
+			require.Equal(t, test.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
 // TODO Validate response
 func Test_RemoveBkt(t *testing.T) {
 	var tests = []struct {
@@ -381,3 +677,458 @@ func Test_RemoveBkt(t *testing.T) {
 		})
 	}
 }
+
+func Test_RemoveProduct(t *testing.T) {
+	var tests = []struct {
+		name            string
+		query           string
+		wantStatus      int
+		mockBktServFunc func() BktService
+	}{
+		{
+			name:       "Remove product - Ok - All units",
+			wantStatus: http.StatusOK,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("RemoveProduct", mock.Anything).Return(bktCreated, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Remove product - Ok - Decrement",
+			query:      "?quantity=2",
+			wantStatus: http.StatusOK,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("RemoveProduct", mock.Anything).Return(bktCreated, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Remove product - BadRequest - Invalid quantity",
+			query:      "?quantity=-1",
+			wantStatus: http.StatusBadRequest,
+			mockBktServFunc: func() BktService {
+				return &ServiceBktMock{}
+			},
+		},
+		{
+			name:       "Remove product - BadRequest - More units than in the basket",
+			query:      "?quantity=5",
+			wantStatus: http.StatusBadRequest,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("RemoveProduct", mock.Anything).Return(basket.Basket{}, fmt.Errorf("%w: the basket has 2 units of \"PEN\"", localMap.ErrInvalidQuantity))
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Remove product - Product not in basket",
+			wantStatus: http.StatusNotFound,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("RemoveProduct", mock.Anything).Return(basket.Basket{}, localMap.ErrProductNotInBasket)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Remove product - Bkt not found",
+			wantStatus: http.StatusNotFound,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("RemoveProduct", mock.Anything).Return(basket.Basket{}, localMap.ErrBktNotFound)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Remove product - Forbidden",
+			wantStatus: http.StatusForbidden,
+			mockBktServFunc: func() BktService {
+				return &ServiceBktMock{}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv(XClientKeyEnvVar, XClientKeyValue)
+			require.NoError(t, err)
+
+			bktHandler := New(test.mockBktServFunc())
+			r := chi.NewRouter()
+			r.Delete("/basket/{basket_id}/product/{product_code}", bktHandler.RemoveProduct)
+
+			rq := httptest.NewRequest(http.MethodDelete, "/basket/"+bktCreated.ID+"/product/PEN"+tt.query, nil)
+			if tt.wantStatus != http.StatusForbidden {
+				rq.Header.Set(XClientKey, XClientKeyValue)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			resp := rr.Result()
+			require.Equal(t, test.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func Test_UpdateProducts(t *testing.T) {
+	var tests = []struct {
+		name            string
+		method          string
+		path            string
+		giveRequest     string
+		wantStatus      int
+		mockBktServFunc func() BktService
+	}{
+		{
+			name:        "Set quantity - Ok",
+			method:      http.MethodPatch,
+			path:        "/product/PEN",
+			giveRequest: `{"quantity": 2}`,
+			wantStatus:  http.StatusOK,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("SetProductQuantity", mock.Anything).Return(bktCreated, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Set quantity - BadRequest - Without quantity",
+			method:      http.MethodPatch,
+			path:        "/product/PEN",
+			giveRequest: `{}`,
+			wantStatus:  http.StatusBadRequest,
+			mockBktServFunc: func() BktService {
+				return &ServiceBktMock{}
+			},
+		},
+		{
+			name:        "Set quantity - BadRequest - Negative quantity",
+			method:      http.MethodPatch,
+			path:        "/product/PEN",
+			giveRequest: `{"quantity": -1}`,
+			wantStatus:  http.StatusBadRequest,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("SetProductQuantity", mock.Anything).Return(basket.Basket{}, localMap.ErrInvalidQuantity)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Set quantity - Conflict - Out of stock",
+			method:      http.MethodPatch,
+			path:        "/product/PEN",
+			giveRequest: `{"quantity": 20}`,
+			wantStatus:  http.StatusConflict,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("SetProductQuantity", mock.Anything).Return(basket.Basket{}, fmt.Errorf("%w: \"PEN\": 5 units available", localMap.ErrOutOfStock))
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Replace products - Ok",
+			method:      http.MethodPut,
+			path:        "/products",
+			giveRequest: `{"products": {"PEN": 2, "MUG": 1}}`,
+			wantStatus:  http.StatusOK,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("ReplaceProducts", mock.Anything).Return(bktCreated, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Replace products - BadRequest - Without products",
+			method:      http.MethodPut,
+			path:        "/products",
+			giveRequest: `{"items": []}`,
+			wantStatus:  http.StatusBadRequest,
+			mockBktServFunc: func() BktService {
+				return &ServiceBktMock{}
+			},
+		},
+		{
+			name:        "Replace products - BadRequest - Invalid product code",
+			method:      http.MethodPut,
+			path:        "/products",
+			giveRequest: `{"products": {"RANDOM": 1}}`,
+			wantStatus:  http.StatusBadRequest,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("ReplaceProducts", mock.Anything).Return(basket.Basket{}, localMap.ErrInvalidProductCode)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Replace products - Bkt not found",
+			method:      http.MethodPut,
+			path:        "/products",
+			giveRequest: `{"products": {}}`,
+			wantStatus:  http.StatusNotFound,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("ReplaceProducts", mock.Anything).Return(basket.Basket{}, localMap.ErrBktNotFound)
+				return &mockTableUpdate
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv(XClientKeyEnvVar, XClientKeyValue)
+			require.NoError(t, err)
+
+			bktHandler := New(test.mockBktServFunc())
+			r := chi.NewRouter()
+			r.Patch("/basket/{basket_id}/product/{product_code}", bktHandler.SetProductQuantity)
+			r.Put("/basket/{basket_id}/products", bktHandler.ReplaceProducts)
+
+			rq := httptest.NewRequest(test.method, "/basket/"+bktCreated.ID+test.path, bytes.NewReader([]byte(test.giveRequest)))
+			rq.Header.Set(XClientKey, XClientKeyValue)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			resp := rr.Result()
+			require.Equal(t, test.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func Test_Version(t *testing.T) {
+	versioned := bktCreated
+	versioned.Version = 3
+	var tests = []struct {
+		name            string
+		method          string
+		ifMatch         string
+		wantStatus      int
+		wantETag        string
+		mockBktServFunc func() BktService
+	}{
+		{
+			name:       "Get - Ok - ETag",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantETag:   `"3"`,
+			mockBktServFunc: func() BktService {
+				mockTableVersion := ServiceBktMock{}
+				mockTableVersion.On("Get", mock.Anything).Return(versioned, nil)
+				return &mockTableVersion
+			},
+		},
+		{
+			name:       "AddProduct - Ok - If-Match",
+			method:     http.MethodPut,
+			ifMatch:    `"3"`,
+			wantStatus: http.StatusOK,
+			wantETag:   `"3"`,
+			mockBktServFunc: func() BktService {
+				mockTableVersion := ServiceBktMock{}
+				mockTableVersion.On("AddProduct", mock.Anything).Return(versioned, nil)
+				return &mockTableVersion
+			},
+		},
+		{
+			name:       "AddProduct - Ok - If-Match any",
+			method:     http.MethodPut,
+			ifMatch:    "*",
+			wantStatus: http.StatusOK,
+			wantETag:   `"3"`,
+			mockBktServFunc: func() BktService {
+				mockTableVersion := ServiceBktMock{}
+				mockTableVersion.On("AddProduct", mock.Anything).Return(versioned, nil)
+				return &mockTableVersion
+			},
+		},
+		{
+			name:       "AddProduct - PreconditionFailed - Basket changed",
+			method:     http.MethodPut,
+			ifMatch:    `"2"`,
+			wantStatus: http.StatusPreconditionFailed,
+			mockBktServFunc: func() BktService {
+				mockTableVersion := ServiceBktMock{}
+				mockTableVersion.On("AddProduct", mock.Anything).Return(basket.Basket{}, localMap.ErrVersionMismatch)
+				return &mockTableVersion
+			},
+		},
+		{
+			name:       "AddProduct - PreconditionFailed - Weak ETag",
+			method:     http.MethodPut,
+			ifMatch:    `W/"3"`,
+			wantStatus: http.StatusPreconditionFailed,
+			mockBktServFunc: func() BktService {
+				return &ServiceBktMock{}
+			},
+		},
+		{
+			name:       "RemoveBkt - PreconditionFailed - Basket changed",
+			method:     http.MethodDelete,
+			ifMatch:    `"2"`,
+			wantStatus: http.StatusPreconditionFailed,
+			mockBktServFunc: func() BktService {
+				mockTableVersion := ServiceBktMock{}
+				mockTableVersion.On("Delete", mock.Anything).Return(localMap.ErrVersionMismatch)
+				return &mockTableVersion
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv(XClientKeyEnvVar, XClientKeyValue)
+			require.NoError(t, err)
+
+			bktHandler := New(test.mockBktServFunc())
+			r := chi.NewRouter()
+			r.Get("/basket/{basket_id}", bktHandler.GetBkt)
+			r.Put("/basket/{basket_id}/product", bktHandler.AddProduct)
+			r.Delete("/basket/{basket_id}", bktHandler.RemoveBkt)
+
+			path := "/basket/" + bktCreated.ID
+			if test.method == http.MethodPut {
+				path += "/product"
+			}
+			rq := httptest.NewRequest(test.method, path, bytes.NewReader([]byte(`{"code": "PEN", "quantity": 1}`)))
+			rq.Header.Set(XClientKey, XClientKeyValue)
+			if test.ifMatch != "" {
+				rq.Header.Set("If-Match", test.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			resp := rr.Result()
+			require.Equal(t, test.wantStatus, resp.StatusCode)
+			require.Equal(t, test.wantETag, resp.Header.Get("ETag"))
+		})
+	}
+}
+
+func Test_ChangeStatus(t *testing.T) {
+	var tests = []struct {
+		name            string
+		path            string
+		wantStatus      int
+		mockBktServFunc func() BktService
+	}{
+		{
+			name:       "Checkout - Ok",
+			path:       "/checkout",
+			wantStatus: http.StatusOK,
+			mockBktServFunc: func() BktService {
+				mockTableStatus := ServiceBktMock{}
+				mockTableStatus.On("Checkout", mock.Anything).Return(bktCreated, nil)
+				return &mockTableStatus
+			},
+		},
+		{
+			name:       "Checkout - Conflict - Empty basket",
+			path:       "/checkout",
+			wantStatus: http.StatusConflict,
+			mockBktServFunc: func() BktService {
+				mockTableStatus := ServiceBktMock{}
+				mockTableStatus.On("Checkout", mock.Anything).Return(basket.Basket{}, localMap.ErrBasketEmpty)
+				return &mockTableStatus
+			},
+		},
+		{
+			name:       "Checkout - Conflict - Illegal transition",
+			path:       "/checkout",
+			wantStatus: http.StatusConflict,
+			mockBktServFunc: func() BktService {
+				mockTableStatus := ServiceBktMock{}
+				mockTableStatus.On("Checkout", mock.Anything).Return(basket.Basket{}, fmt.Errorf("%w: from paid to checking_out", localMap.ErrIllegalTransition))
+				return &mockTableStatus
+			},
+		},
+		{
+			name:       "Checkout - Bkt not found",
+			path:       "/checkout",
+			wantStatus: http.StatusNotFound,
+			mockBktServFunc: func() BktService {
+				mockTableStatus := ServiceBktMock{}
+				mockTableStatus.On("Checkout", mock.Anything).Return(basket.Basket{}, localMap.ErrBktNotFound)
+				return &mockTableStatus
+			},
+		},
+		{
+			name:       "Pay - Ok",
+			path:       "/pay",
+			wantStatus: http.StatusOK,
+			mockBktServFunc: func() BktService {
+				mockTableStatus := ServiceBktMock{}
+				mockTableStatus.On("Pay", mock.Anything).Return(bktCreated, nil)
+				return &mockTableStatus
+			},
+		},
+		{
+			name:       "Pay - Conflict - Not checking out",
+			path:       "/pay",
+			wantStatus: http.StatusConflict,
+			mockBktServFunc: func() BktService {
+				mockTableStatus := ServiceBktMock{}
+				mockTableStatus.On("Pay", mock.Anything).Return(basket.Basket{}, fmt.Errorf("%w: from open to paid", localMap.ErrIllegalTransition))
+				return &mockTableStatus
+			},
+		},
+		{
+			name:       "Pay - Conflict - Coupon exhausted",
+			path:       "/pay",
+			wantStatus: http.StatusConflict,
+			mockBktServFunc: func() BktService {
+				mockTableStatus := ServiceBktMock{}
+				mockTableStatus.On("Pay", mock.Anything).Return(basket.Basket{}, coupon.ErrCouponExhausted)
+				return &mockTableStatus
+			},
+		},
+		{
+			name:       "Pay - InternalServerError",
+			path:       "/pay",
+			wantStatus: http.StatusInternalServerError,
+			mockBktServFunc: func() BktService {
+				mockTableStatus := ServiceBktMock{}
+				mockTableStatus.On("Pay", mock.Anything).Return(basket.Basket{}, errors.New("random error"))
+				return &mockTableStatus
+			},
+		},
+		{
+			name:       "AddProduct - Conflict - Basket not open",
+			path:       "/product",
+			wantStatus: http.StatusConflict,
+			mockBktServFunc: func() BktService {
+				mockTableStatus := ServiceBktMock{}
+				mockTableStatus.On("AddProduct", mock.Anything).Return(basket.Basket{}, localMap.ErrBasketNotOpen)
+				return &mockTableStatus
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv(XClientKeyEnvVar, XClientKeyValue)
+			require.NoError(t, err)
+
+			bktHandler := New(test.mockBktServFunc())
+			r := chi.NewRouter()
+			r.Post("/basket/{basket_id}/checkout", bktHandler.Checkout)
+			r.Post("/basket/{basket_id}/pay", bktHandler.Pay)
+			r.Put("/basket/{basket_id}/product", bktHandler.AddProduct)
+
+			method := http.MethodPost
+			if test.path == "/product" {
+				method = http.MethodPut
+			}
+			rq := httptest.NewRequest(method, "/basket/"+bktCreated.ID+test.path, bytes.NewReader([]byte(`{"code": "PEN", "quantity": 1}`)))
+			rq.Header.Set(XClientKey, XClientKeyValue)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			resp := rr.Result()
+			require.Equal(t, test.wantStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	localLib "github.com/mercadolibre/backend-challenge/local-library"
+)
+
+const (
+	// IdempotencyKeyHeader is the header used by the clients to retry a request safely.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set in the responses replayed from a previous request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// DefaultIdempotencyTTL is how long the responses are kept when no TTL is configured.
+	DefaultIdempotencyTTL   = 24 * time.Hour
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotentResponse is the first response to an idempotency key, empty while the request is in progress.
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+	expiresAt   time.Time
+}
+
+// Idempotency keeps the responses of the requests sent with an Idempotency-Key header
+// per key and client, and replays them when the request is retried with the same key.
+type Idempotency struct {
+	mutex     sync.Mutex
+	responses map[string]*idempotentResponse
+	ttl       time.Duration
+	now       func() time.Time
+	lastPurge time.Time
+}
+
+// NewIdempotency returns an Idempotency keeping the responses for the ttl, DefaultIdempotencyTTL when it is zero.
+func NewIdempotency(ttl time.Duration) *Idempotency {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &Idempotency{
+		responses: make(map[string]*idempotentResponse),
+		ttl:       ttl,
+		now:       time.Now,
+	}
+}
+
+// Middleware replays the stored response of a retried request. Reusing a key with another method, path
+// or body answers 422, and retrying while the first request is in progress answers 409.
+// Server errors are not stored so the request can be retried.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Only the callers with the client key can keep responses, the others get their 403 without storing it.
+		if !isValidCaller(w, r) {
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			localLib.RespondJSON(w, localLib.Error{Message: "Idempotency-Key is too long", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			localLib.RespondJSON(w, localLib.Error{Message: "invalid body", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		fingerprint := sha256.Sum256(bytes.Join([][]byte{[]byte(r.Method), []byte(r.URL.RequestURI()), body}, []byte{0}))
+		scopedKey := r.Header.Get("x-client-key") + "\x00" + key
+
+		stored, ok := i.begin(scopedKey, fingerprint)
+		switch {
+		case ok:
+		case stored.fingerprint != fingerprint:
+			localLib.RespondJSON(w, localLib.Error{Message: "Idempotency-Key already used with another request", StatusCode: http.StatusUnprocessableEntity}, http.StatusUnprocessableEntity)
+			return
+		case !stored.done:
+			localLib.RespondJSON(w, localLib.Error{Message: "a request with this Idempotency-Key is in progress", StatusCode: http.StatusConflict}, http.StatusConflict)
+			return
+		default:
+			for name, values := range stored.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(stored.status)
+			_, _ = w.Write(stored.body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if p := recover(); p != nil {
+				recorder.status = http.StatusInternalServerError
+				i.finish(scopedKey, recorder)
+				panic(p)
+			}
+		}()
+		next.ServeHTTP(recorder, r)
+		i.finish(scopedKey, recorder)
+	})
+}
+
+// begin returns the stored response of the key, or reserves the key for the request and returns true.
+func (i *Idempotency) begin(key string, fingerprint [sha256.Size]byte) (idempotentResponse, bool) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	now := i.now()
+	i.purge(now)
+	if stored, ok := i.responses[key]; ok && now.Before(stored.expiresAt) {
+		return *stored, false
+	}
+	i.responses[key] = &idempotentResponse{fingerprint: fingerprint, expiresAt: now.Add(i.ttl)}
+	return idempotentResponse{}, true
+}
+
+// finish stores the response of the request, or frees the key when it failed.
+func (i *Idempotency) finish(key string, recorder *responseRecorder) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	stored, ok := i.responses[key]
+	if !ok {
+		return
+	}
+	if recorder.status >= http.StatusInternalServerError {
+		delete(i.responses, key)
+		return
+	}
+	stored.done = true
+	stored.status = recorder.status
+	stored.header = recorder.Header().Clone()
+	stored.body = recorder.body.Bytes()
+}
+
+// purge removes the expired responses, at most once per minute. The caller holds the mutex.
+func (i *Idempotency) purge(now time.Time) {
+	if now.Sub(i.lastPurge) < time.Minute {
+		return
+	}
+	i.lastPurge = now
+	for key, stored := range i.responses {
+		if !now.Before(stored.expiresAt) {
+			delete(i.responses, key)
+		}
+	}
+}
+
+// responseRecorder writes the response and keeps a copy of its status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	localMap "github.com/mercadolibre/backend-challenge/internal/basket/local-map"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotency(t *testing.T) {
+	require.NoError(t, os.Setenv(XClientKeyEnvVar, XClientKeyValue))
+	service, err := localMap.New()
+	require.NoError(t, err)
+	r := BasketRoutes(chi.NewRouter(), service, time.Hour)
+
+	send := func(method, path, key, body string) *http.Response {
+		rq := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		rq.Header.Set(XClientKey, XClientKeyValue)
+		if key != "" {
+			rq.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, rq)
+		return rr.Result()
+	}
+	decode := func(resp *http.Response) basket.Basket {
+		var bkt basket.Basket
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&bkt))
+		return bkt
+	}
+
+	first := send(http.MethodPost, "/basket", "create-1", "")
+	require.Equal(t, http.StatusCreated, first.StatusCode)
+	bkt := decode(first)
+	retry := send(http.MethodPost, "/basket", "create-1", "")
+	require.Equal(t, http.StatusCreated, retry.StatusCode)
+	require.Equal(t, "true", retry.Header.Get(IdempotentReplayedHeader))
+	require.Equal(t, bkt.ID, decode(retry).ID)
+	other := send(http.MethodPost, "/basket", "create-2", "")
+	require.NotEqual(t, bkt.ID, decode(other).ID)
+
+	path := "/basket/" + bkt.ID + "/product"
+	tests := []struct {
+		name           string
+		key            string
+		body           string
+		expectedStatus int
+		expectedUnits  int
+	}{
+		{name: "Idempotency - Ok - First request", key: "add-1", body: `{"code": "PEN", "quantity": 2}`, expectedStatus: http.StatusOK, expectedUnits: 2},
+		{name: "Idempotency - Ok - Retry replayed", key: "add-1", body: `{"code": "PEN", "quantity": 2}`, expectedStatus: http.StatusOK, expectedUnits: 2},
+		{name: "Idempotency - Same key with another body", key: "add-1", body: `{"code": "PEN", "quantity": 3}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Idempotency - Ok - Without key", body: `{"code": "PEN", "quantity": 1}`, expectedStatus: http.StatusOK, expectedUnits: 3},
+		{name: "Idempotency - Ok - Error replayed", key: "add-2", body: `{"code": "PEN", "quantity": 0}`, expectedStatus: http.StatusBadRequest},
+		{name: "Idempotency - Ok - Error retry replayed", key: "add-2", body: `{"code": "PEN", "quantity": 0}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := send(http.MethodPut, path, tt.key, tt.body)
+			require.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.expectedUnits > 0 {
+				require.Equal(t, tt.expectedUnits, decode(resp).Products["PEN"])
+			}
+		})
+	}
+
+	got, err := service.Get(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, 3, got.Products["PEN"])
+}
+
+func TestIdempotencyForbidden(t *testing.T) {
+	require.NoError(t, os.Setenv(XClientKeyEnvVar, XClientKeyValue))
+	service, err := localMap.New()
+	require.NoError(t, err)
+	idempotency := NewIdempotency(time.Hour)
+	bktHandler := New(service)
+	r := chi.NewRouter()
+	r.With(idempotency.Middleware).Post("/basket", bktHandler.CreateBkt)
+
+	rq := httptest.NewRequest(http.MethodPost, "/basket", nil)
+	rq.Header.Set(IdempotencyKeyHeader, "create-1")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, rq)
+	require.Equal(t, http.StatusForbidden, rr.Result().StatusCode)
+	require.Empty(t, idempotency.responses)
+}
+
+func TestIdempotencyExpiry(t *testing.T) {
+	require.NoError(t, os.Setenv(XClientKeyEnvVar, XClientKeyValue))
+	now := time.Now()
+	idempotency := NewIdempotency(time.Hour)
+	idempotency.now = func() time.Time { return now }
+	calls := 0
+	h := idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	send := func(key string, fail bool) int {
+		rq := httptest.NewRequest(http.MethodPost, "/basket", nil)
+		rq.Header.Set(XClientKey, XClientKeyValue)
+		rq.Header.Set(IdempotencyKeyHeader, key)
+		if fail {
+			rq.Header.Set("fail", "true")
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, rq)
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusCreated, send("key", false))
+	require.Equal(t, http.StatusCreated, send("key", false))
+	require.Equal(t, 1, calls)
+	now = now.Add(time.Hour)
+	require.Equal(t, http.StatusCreated, send("key", false))
+	require.Equal(t, 2, calls)
+
+	require.Equal(t, http.StatusInternalServerError, send("failed", true))
+	require.Equal(t, http.StatusCreated, send("failed", false))
+	require.Equal(t, 4, calls)
+	require.Len(t, idempotency.responses, 2)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	localMap "github.com/mercadolibre/backend-challenge/internal/basket/local-map"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+	localLib "github.com/mercadolibre/backend-challenge/local-library"
+)
+
+// quoteRequest represents the Quote request.
+// Promotions, when set, replace the configured ones for the quote; At prices the items at another time.
+type quoteRequest struct {
+	Items      []basket.QuoteItem      `json:"items"`
+	Promotions *[]promotion.Definition `json:"promotions,omitempty"`
+	At         *time.Time              `json:"at,omitempty"`
+}
+
+// Quote prices a list of products without creating a basket.
+func (rh *BktHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	if !isValidCaller(w, r) {
+		return
+	}
+
+	var body quoteRequest
+	if err := localLib.Bind(r, &body); err != nil {
+		localLib.RespondJSON(w, localLib.Error{Message: "invalid body", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+	var promotions []promotion.Definition
+	if body.Promotions != nil {
+		promotions = *body.Promotions
+	}
+	var at time.Time
+	if body.At != nil {
+		at = *body.At
+	}
+
+	quote, err := rh.bktService.Quote(body.Items, promotions, at)
+	if err != nil {
+		switch {
+		case err == localMap.ErrInvalidProductCode, errors.Is(err, localMap.ErrInvalidQuantity), err == localMap.ErrVariantRequired,
+			errors.Is(err, promotion.ErrInvalidPromotion):
+			localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		default:
+			// TODO add metrics
+			log.Printf("error in quote: %s", err.Error())
+			localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	quote.FormattedAmount = rh.formatMoney(r, quote.Amount)
+	localLib.RespondJSON(w, quote, http.StatusOK)
+}
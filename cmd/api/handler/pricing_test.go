@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	localMap "github.com/mercadolibre/backend-challenge/internal/basket/local-map"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Quote(t *testing.T) {
+	quoteOk := basket.Quote{
+		Items:      []basket.LineItem{},
+		Subtotal:   basket.NewMoney(3250, basket.DefaultCurrency),
+		Discounts:  []basket.Discount{},
+		Amount:     basket.NewMoney(3250, basket.DefaultCurrency),
+		Promotions: []basket.AppliedPromotion{},
+		Currency:   basket.DefaultCurrency,
+	}
+
+	var tests = []struct {
+		name            string
+		wantStatus      int
+		mockBktServFunc func() BktService
+		giveRequest     string
+	}{
+		{
+			name:        "Quote - Ok",
+			wantStatus:  http.StatusOK,
+			giveRequest: `{"items": ["PEN", "TSHIRT", {"code": "MUG", "quantity": 1}]}`,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("Quote", mock.Anything).Return(quoteOk, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Quote - Forbidden",
+			wantStatus:  http.StatusForbidden,
+			giveRequest: `{"items": ["PEN"]}`,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Quote - BadRequest - Invalid body",
+			wantStatus:  http.StatusBadRequest,
+			giveRequest: `{"items": [1]}`,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Quote - BadRequest - Invalid product code",
+			wantStatus:  http.StatusBadRequest,
+			giveRequest: `{"items": ["RANDOM"]}`,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("Quote", mock.Anything).Return(basket.Quote{}, localMap.ErrInvalidProductCode)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Quote - BadRequest - Invalid promotion",
+			wantStatus:  http.StatusBadRequest,
+			giveRequest: `{"items": ["PEN"], "promotions": [{"name": "pens", "type": "random", "products": ["PEN"]}]}`,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("Quote", mock.Anything).Return(basket.Quote{}, fmt.Errorf("%w: random", promotion.ErrInvalidPromotion))
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Quote - Internal server error",
+			wantStatus:  http.StatusInternalServerError,
+			giveRequest: `{"items": ["PEN"]}`,
+			mockBktServFunc: func() BktService {
+				mockTableUpdate := ServiceBktMock{}
+				mockTableUpdate.On("Quote", mock.Anything).Return(basket.Quote{}, errors.New("random error"))
+				return &mockTableUpdate
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv(XClientKeyEnvVar, XClientKeyValue)
+			require.NoError(t, err)
+
+			bktHandler := New(test.mockBktServFunc())
+			r := chi.NewRouter()
+			r.Post("/pricing/quote", bktHandler.Quote)
+
+			rq := httptest.NewRequest(http.MethodPost, "/pricing/quote", bytes.NewReader([]byte(test.giveRequest)))
+			if tt.wantStatus != http.StatusForbidden {
+				rq.Header.Set(XClientKey, XClientKeyValue)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			resp := rr.Result()
+			require.Equal(t, test.wantStatus, resp.StatusCode)
+			if test.wantStatus == http.StatusOK {
+				var quote basket.Quote
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&quote))
+				require.Equal(t, quoteOk.Amount.Amount, quote.Amount.Amount)
+				require.NotEmpty(t, quote.FormattedAmount)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+	localLib "github.com/mercadolibre/backend-challenge/local-library"
+)
+
+const (
+	productCodeParam   = "product_code"
+	productNotFoundMsg = "product not found"
+	categoryQueryParam = "category"
+)
+
+// A CatalogService interface is used to manage the products of the catalog.
+type CatalogService interface {
+	Products() []basket.Product
+	ProductsByCategory(category string) []basket.Product
+	Product(code string) (basket.Product, error)
+	CreateProduct(product basket.Product) (basket.Product, error)
+	UpdateProduct(code string, product basket.Product) (basket.Product, error)
+	DeleteProduct(code string) error
+}
+
+// ProductHandler is responsible for handle methods related to the catalog.
+type ProductHandler struct {
+	catalogService CatalogService
+}
+
+// NewProductHandler return an instance of ProductHandler.
+func NewProductHandler(catalogService CatalogService) ProductHandler {
+	return ProductHandler{catalogService: catalogService}
+}
+
+// ListProducts returns the products that can be added to a basket, only the ones of the category query parameter when set.
+func (ph *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	if !isValidCaller(w, r) {
+		return
+	}
+	if category := r.URL.Query().Get(categoryQueryParam); category != "" {
+		localLib.RespondJSON(w, ph.catalogService.ProductsByCategory(category), http.StatusOK)
+		return
+	}
+	localLib.RespondJSON(w, ph.catalogService.Products(), http.StatusOK)
+}
+
+// GetProduct returns the product of the code sent by parameter.
+func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	if !isValidCaller(w, r) {
+		return
+	}
+	product, err := ph.catalogService.Product(chi.URLParam(r, productCodeParam))
+	if err != nil {
+		respondProductError(w, "get product", err)
+		return
+	}
+	localLib.RespondJSON(w, product, http.StatusOK)
+}
+
+// CreateProduct adds the product sent in the body to the catalog.
+func (ph *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	if !isValidAdmin(w, r) {
+		return
+	}
+	var body basket.Product
+	if err := localLib.Bind(r, &body); err != nil {
+		localLib.RespondJSON(w, localLib.Error{Message: "invalid body", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+	product, err := ph.catalogService.CreateProduct(body)
+	if err != nil {
+		respondProductError(w, "create product", err)
+		return
+	}
+	localLib.RespondJSON(w, product, http.StatusCreated)
+}
+
+// UpdateProduct replaces the product of the code sent by parameter with the body.
+func (ph *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	if !isValidAdmin(w, r) {
+		return
+	}
+	code := chi.URLParam(r, productCodeParam)
+	var body basket.Product
+	if err := localLib.Bind(r, &body); err != nil {
+		localLib.RespondJSON(w, localLib.Error{Message: "invalid body", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+	if body.Code == "" {
+		body.Code = code
+	}
+	product, err := ph.catalogService.UpdateProduct(code, body)
+	if err != nil {
+		respondProductError(w, "update product", err)
+		return
+	}
+	localLib.RespondJSON(w, product, http.StatusOK)
+}
+
+// DeleteProduct removes the product of the code sent by parameter from the catalog.
+func (ph *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	if !isValidAdmin(w, r) {
+		return
+	}
+	if err := ph.catalogService.DeleteProduct(chi.URLParam(r, productCodeParam)); err != nil {
+		respondProductError(w, "delete product", err)
+		return
+	}
+	localLib.RespondJSON(w, nil, http.StatusNoContent)
+}
+
+func respondProductError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case err == catalog.ErrProductNotFound:
+		localLib.RespondJSON(w, localLib.Error{Message: productNotFoundMsg, StatusCode: http.StatusNotFound}, http.StatusNotFound)
+	case err == catalog.ErrProductExists:
+		localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+	case errors.Is(err, catalog.ErrInvalidProduct):
+		localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+	default:
+		// TODO add metrics
+		log.Printf("error in %s: %s", action, err.Error())
+		localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type ServiceCatalogMock struct {
+	mock.Mock
+}
+
+func (s *ServiceCatalogMock) Products() []basket.Product {
+	args := s.Called()
+	return args.Get(0).([]basket.Product)
+}
+
+func (s *ServiceCatalogMock) ProductsByCategory(_ string) []basket.Product {
+	args := s.Called()
+	return args.Get(0).([]basket.Product)
+}
+
+func (s *ServiceCatalogMock) Product(_ string) (basket.Product, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Product), args.Error(1)
+}
+
+func (s *ServiceCatalogMock) CreateProduct(_ basket.Product) (basket.Product, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Product), args.Error(1)
+}
+
+func (s *ServiceCatalogMock) UpdateProduct(_ string, _ basket.Product) (basket.Product, error) {
+	args := s.Called()
+	return args.Get(0).(basket.Product), args.Error(1)
+}
+
+func (s *ServiceCatalogMock) DeleteProduct(_ string) error {
+	args := s.Called()
+	return args.Error(0)
+}
+
+var penProduct = basket.Product{Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(500, basket.DefaultCurrency)}
+
+func Test_ProductRoutes(t *testing.T) {
+	body := `{"code": "PEN", "name": "Lana Pen", "price": 5}`
+
+	var tests = []struct {
+		name                string
+		method              string
+		path                string
+		giveRequest         string
+		header              string
+		wantStatus          int
+		mockCatalogServFunc func() CatalogService
+	}{
+		{
+			name:       "List products - Ok",
+			method:     http.MethodGet,
+			path:       "/products",
+			header:     XClientKey,
+			wantStatus: http.StatusOK,
+			mockCatalogServFunc: func() CatalogService {
+				mockTableUpdate := ServiceCatalogMock{}
+				mockTableUpdate.On("Products").Return([]basket.Product{penProduct})
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "List products - Ok - By category",
+			method:     http.MethodGet,
+			path:       "/products?category=office",
+			header:     XClientKey,
+			wantStatus: http.StatusOK,
+			mockCatalogServFunc: func() CatalogService {
+				mockTableUpdate := ServiceCatalogMock{}
+				mockTableUpdate.On("ProductsByCategory").Return([]basket.Product{penProduct})
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Get product - Not found",
+			method:     http.MethodGet,
+			path:       "/products/RANDOM",
+			header:     XClientKey,
+			wantStatus: http.StatusNotFound,
+			mockCatalogServFunc: func() CatalogService {
+				mockTableUpdate := ServiceCatalogMock{}
+				mockTableUpdate.On("Product").Return(basket.Product{}, catalog.ErrProductNotFound)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Create product - Forbidden - Client key",
+			method:      http.MethodPost,
+			path:        "/products",
+			giveRequest: body,
+			header:      XClientKey,
+			wantStatus:  http.StatusForbidden,
+			mockCatalogServFunc: func() CatalogService {
+				return &ServiceCatalogMock{}
+			},
+		},
+		{
+			name:        "Create product - Created",
+			method:      http.MethodPost,
+			path:        "/products",
+			giveRequest: body,
+			header:      XAdminKey,
+			wantStatus:  http.StatusCreated,
+			mockCatalogServFunc: func() CatalogService {
+				mockTableUpdate := ServiceCatalogMock{}
+				mockTableUpdate.On("CreateProduct").Return(penProduct, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Create product - Conflict",
+			method:      http.MethodPost,
+			path:        "/products",
+			giveRequest: body,
+			header:      XAdminKey,
+			wantStatus:  http.StatusConflict,
+			mockCatalogServFunc: func() CatalogService {
+				mockTableUpdate := ServiceCatalogMock{}
+				mockTableUpdate.On("CreateProduct").Return(basket.Product{}, catalog.ErrProductExists)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Update product - BadRequest - Invalid product",
+			method:      http.MethodPut,
+			path:        "/products/PEN",
+			giveRequest: body,
+			header:      XAdminKey,
+			wantStatus:  http.StatusBadRequest,
+			mockCatalogServFunc: func() CatalogService {
+				mockTableUpdate := ServiceCatalogMock{}
+				mockTableUpdate.On("UpdateProduct").Return(basket.Product{}, fmt.Errorf("%w: random", catalog.ErrInvalidProduct))
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Update product - Ok",
+			method:      http.MethodPut,
+			path:        "/products/PEN",
+			giveRequest: body,
+			header:      XAdminKey,
+			wantStatus:  http.StatusOK,
+			mockCatalogServFunc: func() CatalogService {
+				mockTableUpdate := ServiceCatalogMock{}
+				mockTableUpdate.On("UpdateProduct").Return(penProduct, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Delete product - No content",
+			method:     http.MethodDelete,
+			path:       "/products/PEN",
+			header:     XAdminKey,
+			wantStatus: http.StatusNoContent,
+			mockCatalogServFunc: func() CatalogService {
+				mockTableUpdate := ServiceCatalogMock{}
+				mockTableUpdate.On("DeleteProduct").Return(nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Delete product - Internal server error",
+			method:     http.MethodDelete,
+			path:       "/products/PEN",
+			header:     XAdminKey,
+			wantStatus: http.StatusInternalServerError,
+			mockCatalogServFunc: func() CatalogService {
+				mockTableUpdate := ServiceCatalogMock{}
+				mockTableUpdate.On("DeleteProduct").Return(errors.New("random error"))
+				return &mockTableUpdate
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, os.Setenv(XClientKeyEnvVar, XClientKeyValue))
+			require.NoError(t, os.Setenv(XAdminKeyEnvVar, XAdminKeyValue))
+
+			r := ProductRoutes(chi.NewRouter(), test.mockCatalogServFunc())
+			rq := httptest.NewRequest(test.method, test.path, bytes.NewReader([]byte(test.giveRequest)))
+			if test.header == XAdminKey {
+				rq.Header.Set(XAdminKey, XAdminKeyValue)
+			} else {
+				rq.Header.Set(XClientKey, XClientKeyValue)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			require.Equal(t, test.wantStatus, rr.Result().StatusCode)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi"
+	localMap "github.com/mercadolibre/backend-challenge/internal/basket/local-map"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+	localLib "github.com/mercadolibre/backend-challenge/local-library"
+)
+
+const (
+	promotionNameParam   = "promotion_name"
+	promotionNotFoundMsg = "promotion not found"
+)
+
+// A PromotionService interface is used to manage the promotions at runtime.
+type PromotionService interface {
+	Promotions() []promotion.Definition
+	Promotion(name string) (promotion.Definition, error)
+	CreatePromotion(def promotion.Definition) (promotion.Definition, error)
+	UpdatePromotion(name string, def promotion.Definition) (promotion.Definition, error)
+	EnablePromotion(name string, enabled bool) (promotion.Definition, error)
+	DeletePromotion(name string) error
+}
+
+// PromotionHandler is responsible for handle the admin methods related to promotions.
+type PromotionHandler struct {
+	prmService PromotionService
+}
+
+// NewPromotionHandler return an instance of PromotionHandler.
+func NewPromotionHandler(prmService PromotionService) PromotionHandler {
+	return PromotionHandler{prmService: prmService}
+}
+
+// ListPromotions returns every promotion, the disabled ones included.
+func (ph *PromotionHandler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	if !isValidAdmin(w, r) {
+		return
+	}
+	localLib.RespondJSON(w, ph.prmService.Promotions(), http.StatusOK)
+}
+
+// GetPromotion returns the promotion of the name sent by parameter.
+func (ph *PromotionHandler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	if !isValidAdmin(w, r) {
+		return
+	}
+	def, err := ph.prmService.Promotion(chi.URLParam(r, promotionNameParam))
+	if err != nil {
+		respondPromotionError(w, "get promotion", err)
+		return
+	}
+	localLib.RespondJSON(w, def, http.StatusOK)
+}
+
+// CreatePromotion adds the promotion sent in the body.
+func (ph *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	if !isValidAdmin(w, r) {
+		return
+	}
+	var body promotion.Definition
+	if err := localLib.Bind(r, &body); err != nil {
+		localLib.RespondJSON(w, localLib.Error{Message: "invalid body", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+	def, err := ph.prmService.CreatePromotion(body)
+	if err != nil {
+		respondPromotionError(w, "create promotion", err)
+		return
+	}
+	localLib.RespondJSON(w, def, http.StatusCreated)
+}
+
+// UpdatePromotion replaces the promotion of the name sent by parameter with the body.
+func (ph *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	if !isValidAdmin(w, r) {
+		return
+	}
+	name := chi.URLParam(r, promotionNameParam)
+	var body promotion.Definition
+	if err := localLib.Bind(r, &body); err != nil {
+		localLib.RespondJSON(w, localLib.Error{Message: "invalid body", StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+	if body.Name == "" {
+		body.Name = name
+	}
+	def, err := ph.prmService.UpdatePromotion(name, body)
+	if err != nil {
+		respondPromotionError(w, "update promotion", err)
+		return
+	}
+	localLib.RespondJSON(w, def, http.StatusOK)
+}
+
+// EnablePromotion enables the promotion of the name sent by parameter.
+func (ph *PromotionHandler) EnablePromotion(w http.ResponseWriter, r *http.Request) {
+	ph.setEnabled(w, r, true)
+}
+
+// DisablePromotion disables the promotion of the name sent by parameter, it stays listed but is not applied.
+func (ph *PromotionHandler) DisablePromotion(w http.ResponseWriter, r *http.Request) {
+	ph.setEnabled(w, r, false)
+}
+
+func (ph *PromotionHandler) setEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	if !isValidAdmin(w, r) {
+		return
+	}
+	def, err := ph.prmService.EnablePromotion(chi.URLParam(r, promotionNameParam), enabled)
+	if err != nil {
+		respondPromotionError(w, "enable promotion", err)
+		return
+	}
+	localLib.RespondJSON(w, def, http.StatusOK)
+}
+
+// DeletePromotion removes the promotion of the name sent by parameter.
+func (ph *PromotionHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	if !isValidAdmin(w, r) {
+		return
+	}
+	if err := ph.prmService.DeletePromotion(chi.URLParam(r, promotionNameParam)); err != nil {
+		respondPromotionError(w, "delete promotion", err)
+		return
+	}
+	localLib.RespondJSON(w, nil, http.StatusNoContent)
+}
+
+func respondPromotionError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case err == localMap.ErrPromotionNotFound:
+		localLib.RespondJSON(w, localLib.Error{Message: promotionNotFoundMsg, StatusCode: http.StatusNotFound}, http.StatusNotFound)
+	case errors.Is(err, promotion.ErrPromotionConflict):
+		localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusConflict}, http.StatusConflict)
+	case errors.Is(err, promotion.ErrInvalidPromotion):
+		localLib.RespondJSON(w, localLib.Error{Message: err.Error(), StatusCode: http.StatusBadRequest}, http.StatusBadRequest)
+	default:
+		// TODO add metrics
+		log.Printf("error in %s: %s", action, err.Error())
+		localLib.RespondJSON(w, localLib.Error{Message: bktInternalServerErrMsg, StatusCode: http.StatusInternalServerError}, http.StatusInternalServerError)
+	}
+}
+
+// isValidAdmin checks the x-admin-key header, the admin endpoints are disabled when X_ADMIN_KEY is not set.
+func isValidAdmin(w http.ResponseWriter, r *http.Request) bool {
+	callerScope := r.Header.Get("x-admin-key")
+	secretCaller := os.Getenv("X_ADMIN_KEY")
+	if secretCaller == "" || callerScope != secretCaller {
+		log.Printf("unauthorized admin caller")
+		localLib.RespondJSON(w, localLib.Error{Message: "Forbidden", StatusCode: http.StatusForbidden}, http.StatusForbidden)
+		return false
+	}
+	return true
+}
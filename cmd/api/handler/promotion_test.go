@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi"
+	localMap "github.com/mercadolibre/backend-challenge/internal/basket/local-map"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	XAdminKey       = "x-admin-key"
+	XAdminKeyValue  = "admin2"
+	XAdminKeyEnvVar = "X_ADMIN_KEY"
+)
+
+type ServicePrmMock struct {
+	mock.Mock
+}
+
+func (s *ServicePrmMock) Promotions() []promotion.Definition {
+	args := s.Called()
+	return args.Get(0).([]promotion.Definition)
+}
+
+func (s *ServicePrmMock) Promotion(_ string) (promotion.Definition, error) {
+	args := s.Called()
+	return args.Get(0).(promotion.Definition), args.Error(1)
+}
+
+func (s *ServicePrmMock) CreatePromotion(_ promotion.Definition) (promotion.Definition, error) {
+	args := s.Called()
+	return args.Get(0).(promotion.Definition), args.Error(1)
+}
+
+func (s *ServicePrmMock) UpdatePromotion(_ string, _ promotion.Definition) (promotion.Definition, error) {
+	args := s.Called()
+	return args.Get(0).(promotion.Definition), args.Error(1)
+}
+
+func (s *ServicePrmMock) EnablePromotion(_ string, _ bool) (promotion.Definition, error) {
+	args := s.Called()
+	return args.Get(0).(promotion.Definition), args.Error(1)
+}
+
+func (s *ServicePrmMock) DeletePromotion(_ string) error {
+	args := s.Called()
+	return args.Error(0)
+}
+
+var penPromotion = promotion.Definition{Name: "pen-2x1", Type: promotion.TypeBuyNPayM, Products: []string{"PEN"}, Buy: 2, Pay: 1}
+
+func Test_PromotionRoutes(t *testing.T) {
+	body := `{"name": "pen-2x1", "type": "buy_n_pay_m", "products": ["PEN"], "buy": 2, "pay": 1}`
+
+	var tests = []struct {
+		name            string
+		method          string
+		path            string
+		giveRequest     string
+		noAdminKey      bool
+		wantStatus      int
+		mockPrmServFunc func() PromotionService
+	}{
+		{
+			name:       "List promotions - Ok",
+			method:     http.MethodGet,
+			path:       "/admin/promotions",
+			wantStatus: http.StatusOK,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("Promotions").Return([]promotion.Definition{penPromotion})
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "List promotions - Forbidden",
+			method:     http.MethodGet,
+			path:       "/admin/promotions",
+			noAdminKey: true,
+			wantStatus: http.StatusForbidden,
+			mockPrmServFunc: func() PromotionService {
+				return &ServicePrmMock{}
+			},
+		},
+		{
+			name:       "Get promotion - Not found",
+			method:     http.MethodGet,
+			path:       "/admin/promotions/random",
+			wantStatus: http.StatusNotFound,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("Promotion").Return(promotion.Definition{}, localMap.ErrPromotionNotFound)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Create promotion - Created",
+			method:      http.MethodPost,
+			path:        "/admin/promotions",
+			giveRequest: body,
+			wantStatus:  http.StatusCreated,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("CreatePromotion").Return(penPromotion, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Create promotion - BadRequest - Invalid body",
+			method:      http.MethodPost,
+			path:        "/admin/promotions",
+			giveRequest: `{"name": 1}`,
+			wantStatus:  http.StatusBadRequest,
+			mockPrmServFunc: func() PromotionService {
+				return &ServicePrmMock{}
+			},
+		},
+		{
+			name:        "Create promotion - BadRequest - Invalid promotion",
+			method:      http.MethodPost,
+			path:        "/admin/promotions",
+			giveRequest: body,
+			wantStatus:  http.StatusBadRequest,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("CreatePromotion").Return(promotion.Definition{}, fmt.Errorf("%w: random", promotion.ErrInvalidPromotion))
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Create promotion - Conflict",
+			method:      http.MethodPost,
+			path:        "/admin/promotions",
+			giveRequest: body,
+			wantStatus:  http.StatusConflict,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("CreatePromotion").Return(promotion.Definition{}, fmt.Errorf("%w: random", promotion.ErrPromotionConflict))
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:        "Update promotion - Ok",
+			method:      http.MethodPut,
+			path:        "/admin/promotions/pen-2x1",
+			giveRequest: body,
+			wantStatus:  http.StatusOK,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("UpdatePromotion").Return(penPromotion, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Disable promotion - Ok",
+			method:     http.MethodPost,
+			path:       "/admin/promotions/pen-2x1/disable",
+			wantStatus: http.StatusOK,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("EnablePromotion").Return(penPromotion, nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Enable promotion - Conflict",
+			method:     http.MethodPost,
+			path:       "/admin/promotions/pen-2x1/enable",
+			wantStatus: http.StatusConflict,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("EnablePromotion").Return(promotion.Definition{}, fmt.Errorf("%w: random", promotion.ErrPromotionConflict))
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Delete promotion - No content",
+			method:     http.MethodDelete,
+			path:       "/admin/promotions/pen-2x1",
+			wantStatus: http.StatusNoContent,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("DeletePromotion").Return(nil)
+				return &mockTableUpdate
+			},
+		},
+		{
+			name:       "Delete promotion - Internal server error",
+			method:     http.MethodDelete,
+			path:       "/admin/promotions/pen-2x1",
+			wantStatus: http.StatusInternalServerError,
+			mockPrmServFunc: func() PromotionService {
+				mockTableUpdate := ServicePrmMock{}
+				mockTableUpdate.On("DeletePromotion").Return(errors.New("random error"))
+				return &mockTableUpdate
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv(XAdminKeyEnvVar, XAdminKeyValue)
+			require.NoError(t, err)
+
+			r := PromotionRoutes(chi.NewRouter(), test.mockPrmServFunc())
+			rq := httptest.NewRequest(test.method, test.path, bytes.NewReader([]byte(test.giveRequest)))
+			if !test.noAdminKey {
+				rq.Header.Set(XAdminKey, XAdminKeyValue)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, rq)
+
+			require.Equal(t, test.wantStatus, rr.Result().StatusCode)
+		})
+	}
+}
@@ -1,15 +1,53 @@
 package handler
 
-import "github.com/go-chi/chi"
+import (
+	"time"
 
-// BasketRoutes mapping cases endpoints.
-func BasketRoutes(r *chi.Mux, bktService BktService) *chi.Mux {
+	"github.com/go-chi/chi"
+)
+
+// BasketRoutes mapping cases endpoints, the mutating ones support the Idempotency-Key header
+// and keep its responses for the idempotencyTTL.
+func BasketRoutes(r *chi.Mux, bktService BktService, idempotencyTTL time.Duration) *chi.Mux {
 	bktHandler := New(bktService)
+	idempotent := r.With(NewIdempotency(idempotencyTTL).Middleware)
 	r.Get("/ping", bktHandler.Ping)
-	r.Post("/basket", bktHandler.CreateBkt)
+	idempotent.Post("/basket", bktHandler.CreateBkt)
 	r.Get("/basket/{basket_id}", bktHandler.GetBkt)
-	r.Put("/basket/{basket_id}/product", bktHandler.AddProduct)
+	idempotent.Put("/basket/{basket_id}/product", bktHandler.AddProduct)
+	idempotent.Delete("/basket/{basket_id}/product/{product_code}", bktHandler.RemoveProduct)
+	idempotent.Patch("/basket/{basket_id}/product/{product_code}", bktHandler.SetProductQuantity)
+	idempotent.Put("/basket/{basket_id}/products", bktHandler.ReplaceProducts)
 	r.Get("/basket/{basket_id}/amount", bktHandler.GetAmount)
-	r.Delete("/basket/{basket_id}", bktHandler.RemoveBkt)
+	idempotent.Put("/basket/{basket_id}/coupon", bktHandler.ApplyCoupon)
+	idempotent.Delete("/basket/{basket_id}/coupon", bktHandler.RemoveCoupon)
+	idempotent.Delete("/basket/{basket_id}", bktHandler.RemoveBkt)
+	idempotent.Post("/basket/{basket_id}/checkout", bktHandler.Checkout)
+	idempotent.Post("/basket/{basket_id}/pay", bktHandler.Pay)
+	r.Post("/pricing/quote", bktHandler.Quote)
+	return r
+}
+
+// PromotionRoutes mapping the admin endpoints of the promotions.
+func PromotionRoutes(r *chi.Mux, prmService PromotionService) *chi.Mux {
+	prmHandler := NewPromotionHandler(prmService)
+	r.Get("/admin/promotions", prmHandler.ListPromotions)
+	r.Post("/admin/promotions", prmHandler.CreatePromotion)
+	r.Get("/admin/promotions/{promotion_name}", prmHandler.GetPromotion)
+	r.Put("/admin/promotions/{promotion_name}", prmHandler.UpdatePromotion)
+	r.Post("/admin/promotions/{promotion_name}/enable", prmHandler.EnablePromotion)
+	r.Post("/admin/promotions/{promotion_name}/disable", prmHandler.DisablePromotion)
+	r.Delete("/admin/promotions/{promotion_name}", prmHandler.DeletePromotion)
+	return r
+}
+
+// ProductRoutes mapping the catalog endpoints, reading needs the client key and writing the admin key.
+func ProductRoutes(r *chi.Mux, catalogService CatalogService) *chi.Mux {
+	prdHandler := NewProductHandler(catalogService)
+	r.Get("/products", prdHandler.ListProducts)
+	r.Post("/products", prdHandler.CreateProduct)
+	r.Get("/products/{product_code}", prdHandler.GetProduct)
+	r.Put("/products/{product_code}", prdHandler.UpdateProduct)
+	r.Delete("/products/{product_code}", prdHandler.DeleteProduct)
 	return r
 }
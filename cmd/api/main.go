@@ -1,9 +1,13 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
@@ -14,21 +18,70 @@ import (
 const (
 	ExitCodeOK = iota
 	ExitCodeFailToCreateWebApplication
+	ExitCodeInvalidConfiguration
 	defaultWebApplicationPort = "8080"
+	promotionsFileEnvVar      = "PROMOTIONS_FILE"
+	couponsFileEnvVar         = "COUPONS_FILE"
+	catalogFileEnvVar         = "CATALOG_FILE"
+	basketTTLEnvVar           = "BASKET_TTL"
+	idempotencyTTLEnvVar      = "IDEMPOTENCY_TTL"
+	expireBasketsInterval     = time.Minute
 )
 
 func main() {
+	catalogFile := flag.String("catalog", os.Getenv(catalogFileEnvVar), "CSV or JSON file with the products of the catalog")
+	dryRun := flag.Bool("dry-run", false, "validate the catalog, promotions and coupons configuration and exit")
+	flag.Parse()
+
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
-	bktService := localMap.New()
-	r = handler.BasketRoutes(r, bktService)
+	var bktTTL time.Duration
+	if value := os.Getenv(basketTTLEnvVar); value != "" {
+		var err error
+		if bktTTL, err = time.ParseDuration(value); err != nil {
+			log.Printf("invalid %s: %s", basketTTLEnvVar, err.Error())
+			os.Exit(ExitCodeInvalidConfiguration)
+		}
+	}
+
+	idempotencyTTL := handler.DefaultIdempotencyTTL
+	if value := os.Getenv(idempotencyTTLEnvVar); value != "" {
+		var err error
+		if idempotencyTTL, err = time.ParseDuration(value); err != nil {
+			log.Printf("invalid %s: %s", idempotencyTTLEnvVar, err.Error())
+			os.Exit(ExitCodeInvalidConfiguration)
+		}
+	}
+
+	bktService, err := localMap.New(
+		localMap.WithCatalogFile(*catalogFile),
+		localMap.WithBasketTTL(bktTTL),
+		localMap.WithPromotionsFile(os.Getenv(promotionsFileEnvVar)),
+		localMap.WithCouponsFile(os.Getenv(couponsFileEnvVar)),
+	)
+	if err != nil {
+		log.Print(err.Error())
+		os.Exit(ExitCodeInvalidConfiguration)
+	}
+	if *dryRun {
+		log.Printf("configuration is valid: %d products in the catalog", len(bktService.Products()))
+		os.Exit(ExitCodeOK)
+	}
+	go reloadOnHangup(bktService)
+	if bktTTL > 0 {
+		go expireBaskets(bktService)
+	}
+
+	r = handler.BasketRoutes(r, bktService, idempotencyTTL)
+	r = handler.PromotionRoutes(r, bktService)
+	r = handler.ProductRoutes(r, bktService)
 
 	log.Print("listen in port: " + defaultWebApplicationPort)
-	err := http.ListenAndServe(":"+defaultWebApplicationPort, r)
+	err = http.ListenAndServe(":"+defaultWebApplicationPort, r)
 	if err != nil {
 		log.Print(err.Error())
 		os.Exit(ExitCodeFailToCreateWebApplication)
@@ -36,3 +89,26 @@ func main() {
 	log.Print("server exit")
 	os.Exit(ExitCodeOK)
 }
+
+// reloadOnHangup reloads the catalog and promotions files each time the process gets a SIGHUP.
+func reloadOnHangup(bktService *localMap.Service) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	for range hangup {
+		diff, err := bktService.Reload()
+		if err != nil {
+			log.Print("reload failed, keeping the configuration in use: " + err.Error())
+			continue
+		}
+		log.Print("configuration reloaded: " + diff.String())
+	}
+}
+
+// expireBaskets releases the stock of the expired baskets periodically.
+func expireBaskets(bktService *localMap.Service) {
+	for range time.Tick(expireBasketsInterval) {
+		if expired := bktService.ExpireBaskets(); expired > 0 {
+			log.Printf("%d baskets expired", expired)
+		}
+	}
+}
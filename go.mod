@@ -4,7 +4,8 @@ go 1.16
 
 require (
 	github.com/go-chi/chi v1.5.4
-	github.com/go-playground/universal-translator v0.18.0 // indirect
+	github.com/go-playground/locales v0.14.0
+	github.com/go-playground/universal-translator v0.18.0
 	github.com/go-playground/validator v9.31.0+incompatible
 	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/rs/xid v1.3.0
@@ -0,0 +1,292 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+)
+
+var (
+	// ErrInvalidProduct is used when a product does not pass the validations.
+	ErrInvalidProduct = errors.New("invalid product")
+	// ErrProductNotFound is used when the code does not belong to a product of the catalog.
+	ErrProductNotFound = errors.New("product not found")
+	// ErrProductExists is used when creating a product with the code of another one, deleted ones included.
+	ErrProductExists = errors.New("product already exists")
+)
+
+// Store keeps the products that can be added to a basket.
+// Deleted products are kept to price the baskets that already have them, but can not be added anymore.
+type Store interface {
+	// List returns the products not deleted, sorted by code.
+	List() []basket.Product
+	// Get returns the product of the code if it is not deleted.
+	Get(code string) (basket.Product, error)
+	Create(product basket.Product) (basket.Product, error)
+	Update(code string, product basket.Product) (basket.Product, error)
+	Delete(code string) error
+	// Consume takes the units out of the stock of the product at once, the stock does not go below zero.
+	// Products without stock are not changed.
+	Consume(code string, quantity int) error
+	// Replace puts the products in place of the current ones as a whole, the ones left out are deleted.
+	// The products it already has keep their stock, the units sold since it was set are not sold again.
+	Replace(products []basket.Product) error
+	// Prices returns every product, deleted ones included, to price the baskets.
+	Prices() map[string]basket.Product
+	// Variants returns the variants of the product that are not deleted, sorted by code.
+	Variants(code string) []basket.Product
+}
+
+// Validate checks the product and sets the default currency to its price when it has none.
+// Every product is priced in the default currency, the one of the baskets.
+func Validate(product basket.Product) (basket.Product, error) {
+	if strings.TrimSpace(product.Code) == "" {
+		return basket.Product{}, fmt.Errorf("%w: code is required", ErrInvalidProduct)
+	}
+	if strings.TrimSpace(product.Name) == "" {
+		return basket.Product{}, fmt.Errorf("%w: %q: name is required", ErrInvalidProduct, product.Code)
+	}
+	if product.Price.Amount < 0 {
+		return basket.Product{}, fmt.Errorf("%w: %q: price can not be negative", ErrInvalidProduct, product.Code)
+	}
+	if product.Stock != nil && *product.Stock < 0 {
+		return basket.Product{}, fmt.Errorf("%w: %q: stock can not be negative", ErrInvalidProduct, product.Code)
+	}
+	if product.Parent == product.Code {
+		return basket.Product{}, fmt.Errorf("%w: %q: a product can not be a variant of itself", ErrInvalidProduct, product.Code)
+	}
+	if product.Price.Currency == "" {
+		product.Price.Currency = basket.DefaultCurrency
+	}
+	if product.Price.Currency != basket.DefaultCurrency {
+		return basket.Product{}, fmt.Errorf("%w: %q: currency %s is not supported, baskets are priced in %s",
+			ErrInvalidProduct, product.Code, product.Price.Currency, basket.DefaultCurrency)
+	}
+	return product, nil
+}
+
+// InCategory reports whether the category or one of the tags of the product is the category.
+func InCategory(product basket.Product, category string) bool {
+	if product.Category == category {
+		return true
+	}
+	for _, tag := range product.Tags {
+		if tag == category {
+			return true
+		}
+	}
+	return false
+}
+
+type entry struct {
+	product basket.Product
+	deleted bool
+}
+
+// Memory is a Store kept in memory. It is safe for concurrent use.
+type Memory struct {
+	mutex    sync.RWMutex
+	products map[string]entry
+}
+
+// NewMemory returns a Memory store with the given products.
+func NewMemory(products ...basket.Product) (*Memory, error) {
+	m := &Memory{products: make(map[string]entry, len(products))}
+	for _, product := range products {
+		if _, err := m.Create(product); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// List returns the products not deleted, sorted by code.
+func (m *Memory) List() []basket.Product {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	products := make([]basket.Product, 0, len(m.products))
+	for _, e := range m.products {
+		if !e.deleted {
+			products = append(products, e.product)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Code < products[j].Code
+	})
+	return products
+}
+
+// Get returns the product of the code if it is not deleted.
+func (m *Memory) Get(code string) (basket.Product, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	e, exists := m.products[code]
+	if !exists || e.deleted {
+		return basket.Product{}, ErrProductNotFound
+	}
+	return e.product, nil
+}
+
+// Create adds the product, its code can not be used by another product even if deleted.
+func (m *Memory) Create(product basket.Product) (basket.Product, error) {
+	product, err := Validate(product)
+	if err != nil {
+		return basket.Product{}, err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, exists := m.products[product.Code]; exists {
+		return basket.Product{}, ErrProductExists
+	}
+	if err := checkParent(product, m.products); err != nil {
+		return basket.Product{}, err
+	}
+	m.products[product.Code] = entry{product: product}
+	return product, nil
+}
+
+// Update replaces the product of the code, the code can not change.
+func (m *Memory) Update(code string, product basket.Product) (basket.Product, error) {
+	if product.Code != code {
+		return basket.Product{}, fmt.Errorf("%w: code %q does not match %q", ErrInvalidProduct, product.Code, code)
+	}
+	product, err := Validate(product)
+	if err != nil {
+		return basket.Product{}, err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	e, exists := m.products[code]
+	if !exists || e.deleted {
+		return basket.Product{}, ErrProductNotFound
+	}
+	if err := checkParent(product, m.products); err != nil {
+		return basket.Product{}, err
+	}
+	m.products[code] = entry{product: product}
+	return product, nil
+}
+
+// Delete soft deletes the product.
+func (m *Memory) Delete(code string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	e, exists := m.products[code]
+	if !exists || e.deleted {
+		return ErrProductNotFound
+	}
+	e.deleted = true
+	m.products[code] = e
+	return nil
+}
+
+// Consume takes the units out of the stock of the product under the lock, so concurrent updates are not lost.
+func (m *Memory) Consume(code string, quantity int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	e, exists := m.products[code]
+	if !exists || e.deleted {
+		return ErrProductNotFound
+	}
+	if e.product.Stock == nil {
+		return nil
+	}
+	stock := *e.product.Stock - quantity
+	if stock < 0 {
+		stock = 0
+	}
+	e.product.Stock = &stock
+	m.products[code] = e
+	return nil
+}
+
+// Replace validates every product and then swaps them with the current ones at once.
+// The products left out are kept as deleted so the baskets that have them keep pricing them,
+// and the products that track their stock in both keep the current one.
+func (m *Memory) Replace(products []basket.Product) error {
+	replaced := make(map[string]entry, len(products))
+	for _, product := range products {
+		product, err := Validate(product)
+		if err != nil {
+			return err
+		}
+		if _, exists := replaced[product.Code]; exists {
+			return fmt.Errorf("%w: %q: duplicated code", ErrInvalidProduct, product.Code)
+		}
+		replaced[product.Code] = entry{product: product}
+	}
+	for _, e := range replaced {
+		if err := checkParent(e.product, replaced); err != nil {
+			return err
+		}
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for code, e := range m.products {
+		r, exists := replaced[code]
+		if !exists {
+			e.deleted = true
+			replaced[code] = e
+			continue
+		}
+		if !e.deleted && e.product.Stock != nil && r.product.Stock != nil {
+			stock := *e.product.Stock
+			r.product.Stock = &stock
+			replaced[code] = r
+		}
+	}
+	m.products = replaced
+	return nil
+}
+
+// Prices returns a copy of every product, deleted ones included.
+func (m *Memory) Prices() map[string]basket.Product {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	products := make(map[string]basket.Product, len(m.products))
+	for code, e := range m.products {
+		products[code] = e.product
+	}
+	return products
+}
+
+// Variants returns the variants of the product that are not deleted, sorted by code.
+func (m *Memory) Variants(code string) []basket.Product {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	var variants []basket.Product
+	for _, e := range m.products {
+		if !e.deleted && e.product.Parent == code {
+			variants = append(variants, e.product)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool {
+		return variants[i].Code < variants[j].Code
+	})
+	return variants
+}
+
+// checkParent checks the parent of a variant is a product of the catalog that is not a variant,
+// and that a product with variants does not become a variant itself.
+func checkParent(product basket.Product, products map[string]entry) error {
+	if product.Parent == "" {
+		return nil
+	}
+	parent, exists := products[product.Parent]
+	if !exists || parent.deleted {
+		return fmt.Errorf("%w: %q: parent %q not found", ErrInvalidProduct, product.Code, product.Parent)
+	}
+	if parent.product.Parent != "" {
+		return fmt.Errorf("%w: %q: parent %q is a variant", ErrInvalidProduct, product.Code, product.Parent)
+	}
+	for _, e := range products {
+		if !e.deleted && e.product.Parent == product.Code {
+			return fmt.Errorf("%w: %q: a product with variants can not be a variant", ErrInvalidProduct, product.Code)
+		}
+	}
+	return nil
+}
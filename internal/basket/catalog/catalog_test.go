@@ -0,0 +1,226 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	pen := basket.Product{Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(500, basket.DefaultCurrency)}
+	store, err := NewMemory(pen)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		run         func() error
+		expectedErr error
+	}{
+		{
+			name: "Create - Ok - Default currency",
+			run: func() error {
+				mug, err := store.Create(basket.Product{Code: "MUG", Name: "Lana Coffee Mug", Price: basket.NewMoney(750, "")})
+				require.Equal(t, basket.DefaultCurrency, mug.Price.Currency)
+				return err
+			},
+		},
+		{
+			name: "Create - Exists",
+			run: func() error {
+				_, err := store.Create(pen)
+				return err
+			},
+			expectedErr: ErrProductExists,
+		},
+		{
+			name: "Create - Negative price",
+			run: func() error {
+				_, err := store.Create(basket.Product{Code: "CAP", Name: "Lana Cap", Price: basket.NewMoney(-1, "")})
+				return err
+			},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name: "Create - Negative stock",
+			run: func() error {
+				stock := -1
+				_, err := store.Create(basket.Product{Code: "CAP", Name: "Lana Cap", Stock: &stock})
+				return err
+			},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name: "Create - Without name",
+			run: func() error {
+				_, err := store.Create(basket.Product{Code: "CAP"})
+				return err
+			},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name: "Update - Ok",
+			run: func() error {
+				_, err := store.Update("PEN", basket.Product{Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(600, "")})
+				return err
+			},
+		},
+		{
+			name: "Update - Code does not match",
+			run: func() error {
+				_, err := store.Update("PEN", basket.Product{Code: "MUG", Name: "Lana Pen"})
+				return err
+			},
+			expectedErr: ErrInvalidProduct,
+		},
+		{
+			name: "Update - Not found",
+			run: func() error {
+				_, err := store.Update("CAP", basket.Product{Code: "CAP", Name: "Lana Cap"})
+				return err
+			},
+			expectedErr: ErrProductNotFound,
+		},
+		{
+			name:        "Delete - Not found",
+			run:         func() error { return store.Delete("CAP") },
+			expectedErr: ErrProductNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			require.True(t, errors.Is(err, tt.expectedErr))
+		})
+	}
+
+	require.NoError(t, store.Delete("MUG"))
+	_, err = store.Get("MUG")
+	require.Equal(t, ErrProductNotFound, err)
+	require.Equal(t, ErrProductNotFound, store.Delete("MUG"))
+	_, err = store.Create(basket.Product{Code: "MUG", Name: "Lana Coffee Mug"})
+	require.Equal(t, ErrProductExists, err)
+
+	require.Equal(t, []basket.Product{
+		{Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(600, basket.DefaultCurrency)},
+	}, store.List())
+	require.Len(t, store.Prices(), 2)
+}
+
+func TestMemoryConsume(t *testing.T) {
+	stock := 3
+	pen := basket.Product{Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(500, basket.DefaultCurrency), Stock: &stock}
+	mug := basket.Product{Code: "MUG", Name: "Lana Mug", Price: basket.NewMoney(750, basket.DefaultCurrency)}
+	store, err := NewMemory(pen, mug)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		code          string
+		quantity      int
+		expectedStock *int
+		expectedErr   error
+	}{
+		{name: "Consume - Ok", code: "PEN", quantity: 2, expectedStock: intPtr(1)},
+		{name: "Consume - Not below zero", code: "PEN", quantity: 2, expectedStock: intPtr(0)},
+		{name: "Consume - Without stock", code: "MUG", quantity: 2},
+		{name: "Consume - Not found", code: "CAP", quantity: 1, expectedErr: ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.Consume(tt.code, tt.quantity)
+			require.Equal(t, tt.expectedErr, err)
+			if err == nil {
+				product, err := store.Get(tt.code)
+				require.NoError(t, err)
+				require.Equal(t, tt.expectedStock, product.Stock)
+			}
+		})
+	}
+
+	// The stock is not shared with the products returned before.
+	require.Equal(t, 3, stock)
+}
+
+func TestMemoryReplace(t *testing.T) {
+	pen := basket.Product{Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(500, basket.DefaultCurrency)}
+	mug := basket.Product{Code: "MUG", Name: "Lana Coffee Mug", Price: basket.NewMoney(750, basket.DefaultCurrency)}
+	lanaCap := basket.Product{Code: "CAP", Name: "Lana Cap", Price: basket.NewMoney(1250, basket.DefaultCurrency)}
+	store, err := NewMemory(pen, mug)
+	require.NoError(t, err)
+
+	err = store.Replace([]basket.Product{pen, {Code: "CAP"}})
+	require.True(t, errors.Is(err, ErrInvalidProduct))
+	err = store.Replace([]basket.Product{pen, pen})
+	require.True(t, errors.Is(err, ErrInvalidProduct))
+	require.Equal(t, []basket.Product{mug, pen}, store.List())
+
+	require.NoError(t, store.Replace([]basket.Product{lanaCap, pen}))
+	require.Equal(t, []basket.Product{lanaCap, pen}, store.List())
+	_, err = store.Get("MUG")
+	require.Equal(t, ErrProductNotFound, err)
+	require.Equal(t, mug, store.Prices()["MUG"])
+
+	// The stock left is kept, the one of the new products is set.
+	stocked := pen
+	stocked.Stock = intPtr(5)
+	require.NoError(t, store.Replace([]basket.Product{stocked}))
+	require.NoError(t, store.Consume("PEN", 5))
+	stocked.Name = "Lana Pen Blue"
+	require.NoError(t, store.Replace([]basket.Product{stocked}))
+	got, err := store.Get("PEN")
+	require.NoError(t, err)
+	require.Equal(t, "Lana Pen Blue", got.Name)
+	require.Equal(t, intPtr(0), got.Stock)
+}
+
+func TestMemoryVariants(t *testing.T) {
+	tshirt := basket.Product{Code: "TSHIRT", Name: "Lana T-Shirt", Price: basket.NewMoney(2000, basket.DefaultCurrency)}
+	small := basket.Product{Code: "TSHIRT-S", Name: "Lana T-Shirt S", Price: basket.NewMoney(2000, basket.DefaultCurrency),
+		Parent: "TSHIRT", Attributes: map[string]string{"size": "S"}}
+	store, err := NewMemory(tshirt, small)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		product     basket.Product
+		expectedErr error
+	}{
+		{name: "Variant - Ok", product: basket.Product{Code: "TSHIRT-M", Name: "Lana T-Shirt M", Parent: "TSHIRT"}},
+		{name: "Variant - Parent not found", product: basket.Product{Code: "CAP-S", Name: "Lana Cap S", Parent: "CAP"}, expectedErr: ErrInvalidProduct},
+		{name: "Variant - Parent is a variant", product: basket.Product{Code: "TSHIRT-S-RED", Name: "Lana T-Shirt S red", Parent: "TSHIRT-S"}, expectedErr: ErrInvalidProduct},
+		{name: "Variant - Of itself", product: basket.Product{Code: "CAP", Name: "Lana Cap", Parent: "CAP"}, expectedErr: ErrInvalidProduct},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Create(tt.product)
+			require.True(t, errors.Is(err, tt.expectedErr))
+		})
+	}
+
+	require.Equal(t, []string{"TSHIRT-M", "TSHIRT-S"}, codes(store.Variants("TSHIRT")))
+	require.Empty(t, store.Variants("TSHIRT-S"))
+	_, err = store.Update("TSHIRT", basket.Product{Code: "TSHIRT", Name: "Lana T-Shirt", Parent: "TSHIRT-S"})
+	require.True(t, errors.Is(err, ErrInvalidProduct))
+	require.NoError(t, store.Delete("TSHIRT-M"))
+	require.Equal(t, []string{"TSHIRT-S"}, codes(store.Variants("TSHIRT")))
+
+	err = store.Replace([]basket.Product{small})
+	require.True(t, errors.Is(err, ErrInvalidProduct))
+}
+
+func codes(products []basket.Product) []string {
+	codes := make([]string, 0, len(products))
+	for _, product := range products {
+		codes = append(codes, product.Code)
+	}
+	return codes
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,261 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+)
+
+var (
+	// ErrInvalidCatalog is used when a catalog file can not be loaded.
+	ErrInvalidCatalog = errors.New("invalid catalog")
+)
+
+// RowError is the error of a row of a catalog file. Row is the line of a CSV file, or the position of a JSON product.
+type RowError struct {
+	Row  int
+	Code string
+	Err  error
+}
+
+func (e RowError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Err.Error())
+	}
+	return fmt.Sprintf("row %d: %s: %s", e.Row, e.Code, e.Err.Error())
+}
+
+// ImportError reports every invalid row of a catalog file, so all of them can be fixed at once.
+type ImportError struct {
+	Path string
+	Rows []RowError
+}
+
+func (e *ImportError) Error() string {
+	lines := make([]string, 0, len(e.Rows)+1)
+	lines = append(lines, fmt.Sprintf("%s: %s: %d invalid rows", ErrInvalidCatalog.Error(), e.Path, len(e.Rows)))
+	for _, row := range e.Rows {
+		lines = append(lines, "  "+row.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap allows checking the error with errors.Is(err, ErrInvalidCatalog).
+func (e *ImportError) Unwrap() error {
+	return ErrInvalidCatalog
+}
+
+// fileProduct is a product as written in a catalog file, the price is parsed with the currency decimals.
+type fileProduct struct {
+	Code     string      `json:"code"`
+	Name     string      `json:"name"`
+	Price    json.Number `json:"price"`
+	Currency string      `json:"currency"`
+	Category string      `json:"category"`
+	Stock    *int        `json:"stock"`
+	Parent   string      `json:"parent"`
+	// Tags are written "gift;summer" in a CSV file.
+	Tags []string `json:"tags"`
+	// Attributes are written "size=M;colour=red" in a CSV file.
+	Attributes map[string]string `json:"attributes"`
+	// invalidStock and invalidAttributes are the values of a CSV row that can not be parsed, reported when the row is validated.
+	invalidStock      string
+	invalidAttributes string
+	// columns is the number of columns of a CSV row that does not have those of the header, zero otherwise.
+	columns, headerColumns int
+}
+
+// LoadFile reads a catalog file, CSV or JSON by its extension, and validates every product.
+//
+// A CSV file has a header with the columns code, name, price and optionally currency, category, tags, stock, parent and attributes.
+// A JSON file is {"products": [{"code": "PEN", "name": "Lana Pen", "price": 5, "currency": "EUR", "category": "office", "stock": 100}]}.
+// The stock of a product left empty is not tracked. Variants name their parent, which must be in the same file.
+func LoadFile(path string) ([]basket.Product, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rows []fileProduct
+	var firstRow int
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = readCSV(file)
+		firstRow = 2
+	case ".json":
+		rows, err = readJSON(file)
+		firstRow = 1
+	default:
+		return nil, fmt.Errorf("%w: %s: unsupported extension, use .csv or .json", ErrInvalidCatalog, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidCatalog, path, err.Error())
+	}
+
+	importErr := &ImportError{Path: path}
+	products := make([]basket.Product, 0, len(rows))
+	codes := make(map[string]int, len(rows))
+	for i, row := range rows {
+		rowNumber := firstRow + i
+		product, err := row.product()
+		if err == nil {
+			if previous, exists := codes[product.Code]; exists {
+				err = fmt.Errorf("%w: duplicated code, first seen in row %d", ErrInvalidProduct, previous)
+			}
+		}
+		if err != nil {
+			importErr.Rows = append(importErr.Rows, RowError{Row: rowNumber, Code: row.Code, Err: err})
+			continue
+		}
+		codes[product.Code] = rowNumber
+		products = append(products, product)
+	}
+	entries := make(map[string]entry, len(products))
+	for _, product := range products {
+		entries[product.Code] = entry{product: product}
+	}
+	for _, product := range products {
+		if err := checkParent(product, entries); err != nil {
+			importErr.Rows = append(importErr.Rows, RowError{Row: codes[product.Code], Code: product.Code, Err: err})
+		}
+	}
+	if len(importErr.Rows) > 0 {
+		sort.SliceStable(importErr.Rows, func(i, j int) bool {
+			return importErr.Rows[i].Row < importErr.Rows[j].Row
+		})
+		return nil, importErr
+	}
+	return products, nil
+}
+
+func (p fileProduct) product() (basket.Product, error) {
+	if p.columns != p.headerColumns {
+		return basket.Product{}, fmt.Errorf("%w: the row has %d columns, the header has %d", ErrInvalidProduct, p.columns, p.headerColumns)
+	}
+	if p.invalidStock != "" {
+		return basket.Product{}, fmt.Errorf("%w: stock %q is not a number of units", ErrInvalidProduct, p.invalidStock)
+	}
+	if p.invalidAttributes != "" {
+		return basket.Product{}, fmt.Errorf("%w: attributes %q must be written as key=value;key=value", ErrInvalidProduct, p.invalidAttributes)
+	}
+	currency := strings.ToUpper(strings.TrimSpace(p.Currency))
+	price, err := basket.ParseMoney(p.Price.String(), currency)
+	if err != nil {
+		return basket.Product{}, fmt.Errorf("%w: price %q is not a valid amount", ErrInvalidProduct, p.Price.String())
+	}
+	return Validate(basket.Product{
+		Code:       strings.TrimSpace(p.Code),
+		Name:       p.Name,
+		Price:      price,
+		Category:   strings.TrimSpace(p.Category),
+		Stock:      p.Stock,
+		Tags:       p.Tags,
+		Parent:     strings.TrimSpace(p.Parent),
+		Attributes: p.Attributes,
+	})
+}
+
+func readJSON(r io.Reader) ([]fileProduct, error) {
+	var file struct {
+		Products []fileProduct `json:"products"`
+	}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+	return file.Products, nil
+}
+
+func readCSV(r io.Reader) ([]fileProduct, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	// Rows with another number of columns are reported with the rest of the invalid rows.
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("header: %s", err.Error())
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"code", "name", "price"} {
+		if _, exists := columns[required]; !exists {
+			return nil, fmt.Errorf("header: column %q is required", required)
+		}
+	}
+
+	column := func(record []string, name string) string {
+		if i, exists := columns[name]; exists && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	var rows []fileProduct
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := fileProduct{
+			Code:     column(record, "code"),
+			Name:     column(record, "name"),
+			Price:    json.Number(column(record, "price")),
+			Currency: column(record, "currency"),
+			Category: column(record, "category"),
+		}
+		row.Parent = column(record, "parent")
+		if len(record) != len(header) {
+			row.columns, row.headerColumns = len(record), len(header)
+		}
+		for _, tag := range strings.Split(column(record, "tags"), ";") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				row.Tags = append(row.Tags, tag)
+			}
+		}
+		if attributes := strings.TrimSpace(column(record, "attributes")); attributes != "" {
+			if row.Attributes = parseAttributes(attributes); row.Attributes == nil {
+				row.invalidAttributes = attributes
+			}
+		}
+		if stock := strings.TrimSpace(column(record, "stock")); stock != "" {
+			if units, err := strconv.Atoi(stock); err == nil {
+				row.Stock = &units
+			} else {
+				row.invalidStock = stock
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// parseAttributes parses "size=M;colour=red", it returns nil when a pair has no key.
+func parseAttributes(value string) map[string]string {
+	attributes := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || key == "" {
+			return nil
+		}
+		attributes[key] = strings.TrimSpace(parts[1])
+	}
+	return attributes
+}
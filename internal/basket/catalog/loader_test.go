@@ -0,0 +1,133 @@
+package catalog
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile(t *testing.T) {
+	pen := basket.Product{Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(500, basket.DefaultCurrency), Category: "office"}
+	mug := basket.Product{Code: "MUG", Name: "Lana Coffee Mug", Price: basket.NewMoney(750, basket.DefaultCurrency)}
+	stock := 25
+	tests := []struct {
+		name         string
+		file         string
+		content      string
+		expected     []basket.Product
+		expectedRows []int
+		expectedErr  bool
+	}{
+		{
+			name:     "Load - Ok - CSV",
+			file:     "catalog.csv",
+			content:  "code,name,price,currency,category\nPEN,Lana Pen,5,EUR,office\nMUG,Lana Coffee Mug,7.50,,\n",
+			expected: []basket.Product{pen, mug},
+		},
+		{
+			name:     "Load - Ok - CSV with stock",
+			file:     "catalog.csv",
+			content:  "code,name,price,stock\nPEN,Lana Pen,5,25\nMUG,Lana Coffee Mug,7.5,\n",
+			expected: []basket.Product{{Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(500, basket.DefaultCurrency), Stock: &stock}, mug},
+		},
+		{
+			name:         "Load - Error - Invalid stock",
+			file:         "catalog.csv",
+			content:      "code,name,price,stock\nPEN,Lana Pen,5,many\nMUG,Lana Coffee Mug,7.5,-1\n",
+			expectedRows: []int{2, 3},
+		},
+		{
+			name:    "Load - Ok - CSV with variants",
+			file:    "catalog.csv",
+			content: "code,name,price,parent,attributes\nTSHIRT,Lana T-Shirt,20,,\nTSHIRT-M,Lana T-Shirt M,20,TSHIRT,size=M;colour=red\n",
+			expected: []basket.Product{
+				{Code: "TSHIRT", Name: "Lana T-Shirt", Price: basket.NewMoney(2000, basket.DefaultCurrency)},
+				{Code: "TSHIRT-M", Name: "Lana T-Shirt M", Price: basket.NewMoney(2000, basket.DefaultCurrency), Parent: "TSHIRT",
+					Attributes: map[string]string{"size": "M", "colour": "red"}},
+			},
+		},
+		{
+			name:         "Load - Error - Invalid variants",
+			file:         "catalog.csv",
+			content:      "code,name,price,parent,attributes\nTSHIRT-S,Lana T-Shirt S,20,TSHIRT,size=S\nPEN,Lana Pen,5,,\nPEN-BLUE,Lana Pen blue,5,PEN,=blue\n",
+			expectedRows: []int{2, 4},
+		},
+		{
+			name:     "Load - Ok - CSV columns in any order",
+			file:     "catalog.csv",
+			content:  "Price,Code,Name,Tags\n7.5,MUG,Lana Coffee Mug,\n10,BOTTLE,Lana Bottle,drinkware; outdoor\n",
+			expected: []basket.Product{mug, {Code: "BOTTLE", Name: "Lana Bottle", Price: basket.NewMoney(1000, basket.DefaultCurrency), Tags: []string{"drinkware", "outdoor"}}},
+		},
+		{
+			name: "Load - Ok - JSON",
+			file: "catalog.json",
+			content: `{"products": [
+				{"code": "PEN", "name": "Lana Pen", "price": 5, "currency": "EUR", "category": "office"},
+				{"code": "MUG", "name": "Lana Coffee Mug", "price": 7.50}
+			]}`,
+			expected: []basket.Product{pen, mug},
+		},
+		{
+			name:         "Load - Error - CSV rows",
+			file:         "catalog.csv",
+			content:      "code,name,price,currency\nPEN,Lana Pen,5,EUR\n,No code,1,EUR\nCAP,Lana Cap,-1,EUR\nPEN,Lana Pen,5,EUR\nHAT,Lana Hat,five,EUR\nBAG,Lana Bag,5,USD\nMUG,Lana Coffee Mug,7.50,EUR\n",
+			expectedRows: []int{3, 4, 5, 6, 7},
+		},
+		{
+			name:         "Load - Error - CSV rows with other columns",
+			file:         "catalog.csv",
+			content:      "code,name,price\nPEN,Lana Pen,5\nCAP,Lana Cap\nHAT,Lana Hat,five\nMUG,Lana Coffee Mug,7.50,EUR\n",
+			expectedRows: []int{3, 4, 5},
+		},
+		{
+			name:         "Load - Error - JSON rows",
+			file:         "catalog.json",
+			content:      `{"products": [{"code": "PEN", "price": 5}, {"code": "MUG", "name": "Lana Coffee Mug", "price": 7.505}]}`,
+			expectedRows: []int{1, 2},
+		},
+		{
+			name:        "Load - Error - CSV without price column",
+			file:        "catalog.csv",
+			content:     "code,name\nPEN,Lana Pen\n",
+			expectedErr: true,
+		},
+		{
+			name:        "Load - Error - Unsupported extension",
+			file:        "catalog.txt",
+			content:     "PEN",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, ioutil.WriteFile(path, []byte(tt.content), 0600))
+
+			products, err := LoadFile(path)
+			if tt.expectedErr || len(tt.expectedRows) > 0 {
+				require.True(t, errors.Is(err, ErrInvalidCatalog))
+				var importErr *ImportError
+				if errors.As(err, &importErr) {
+					rows := make([]int, 0, len(importErr.Rows))
+					for _, row := range importErr.Rows {
+						rows = append(rows, row.Row)
+					}
+					require.Equal(t, tt.expectedRows, rows)
+				} else {
+					require.Empty(t, tt.expectedRows)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, products)
+		})
+	}
+
+	_, err := LoadFile(filepath.Join(t.TempDir(), "missing.csv"))
+	require.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package coupon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+)
+
+// Supported coupon types.
+const (
+	TypePercentage = "percentage"
+	TypeFixed      = "fixed"
+)
+
+var (
+	// ErrInvalidCoupon is used when a coupon definition can not be built.
+	ErrInvalidCoupon = errors.New("invalid coupon")
+	// ErrCouponNotFound is used when the code does not belong to any coupon.
+	ErrCouponNotFound = errors.New("coupon not found")
+	// ErrCouponExpired is used when the coupon expiration date has passed.
+	ErrCouponExpired = errors.New("coupon expired")
+	// ErrCouponExhausted is used when the coupon reached its maximum redemptions.
+	ErrCouponExhausted = errors.New("coupon exhausted")
+	// ErrMinAmountNotReached is used when the basket amount is lower than the coupon minimum.
+	ErrMinAmountNotReached = errors.New("basket amount lower than the coupon minimum")
+)
+
+// Config is the coupons configuration file.
+type Config struct {
+	Coupons []Definition `json:"coupons"`
+}
+
+// Definition describes a coupon as written in the configuration file.
+//
+//	{"code": "LANA10", "type": "percentage", "discount_percent": 10, "min_amount": 20, "expires_at": "2030-01-01T00:00:00Z", "max_redemptions": 100}
+type Definition struct {
+	Code            string        `json:"code"`
+	Type            string        `json:"type"`
+	DiscountPercent float64       `json:"discount_percent,omitempty"`
+	Amount          *basket.Money `json:"amount,omitempty"`
+	MinAmount       *basket.Money `json:"min_amount,omitempty"`
+	ExpiresAt       *time.Time    `json:"expires_at,omitempty"`
+	MaxRedemptions  int           `json:"max_redemptions,omitempty"`
+}
+
+// Coupon is a validated coupon.
+type Coupon struct {
+	Code string
+	// Percent is the discount in basis points, used by percentage coupons.
+	Percent int64
+	// Amount is the discount of fixed coupons.
+	Amount         basket.Money
+	MinAmount      basket.Money
+	ExpiresAt      *time.Time
+	MaxRedemptions int
+}
+
+// DefaultConfig returns the coupons available when no configuration file is set.
+func DefaultConfig() Config {
+	return Config{
+		Coupons: []Definition{
+			{Code: "LANA10", Type: TypePercentage, DiscountPercent: 10},
+		},
+	}
+}
+
+// LoadConfig reads and validates the coupons configuration file.
+func LoadConfig(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %s: %s", ErrInvalidCoupon, path, err.Error())
+	}
+	if _, err := NewRegistry(cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Build validates the definition and returns its coupon.
+func (d Definition) Build() (Coupon, error) {
+	if d.Code == "" {
+		return Coupon{}, fmt.Errorf("%w: code is required", ErrInvalidCoupon)
+	}
+	if d.MaxRedemptions < 0 {
+		return Coupon{}, fmt.Errorf("%w: %q: max_redemptions can not be negative", ErrInvalidCoupon, d.Code)
+	}
+	c := Coupon{Code: normalize(d.Code), ExpiresAt: d.ExpiresAt, MaxRedemptions: d.MaxRedemptions}
+	if d.MinAmount != nil {
+		if d.MinAmount.Amount < 0 {
+			return Coupon{}, fmt.Errorf("%w: %q: min_amount can not be negative", ErrInvalidCoupon, d.Code)
+		}
+		c.MinAmount = *d.MinAmount
+	}
+
+	switch d.Type {
+	case TypePercentage:
+		percent, err := basket.BasisPoints(d.DiscountPercent)
+		if err != nil {
+			return Coupon{}, fmt.Errorf("%w: %q: %s", ErrInvalidCoupon, d.Code, err.Error())
+		}
+		c.Percent = percent
+	case TypeFixed:
+		if d.Amount == nil || d.Amount.Amount <= 0 {
+			return Coupon{}, fmt.Errorf("%w: %q: amount must be greater than zero", ErrInvalidCoupon, d.Code)
+		}
+		c.Amount = *d.Amount
+	default:
+		return Coupon{}, fmt.Errorf("%w: %q: unknown type %q", ErrInvalidCoupon, d.Code, d.Type)
+	}
+	return c, nil
+}
+
+// Discount returns the discount of the coupon over amount, never greater than amount.
+// It is zero when the coupon expired or the amount does not reach the minimum.
+func (c Coupon) Discount(amount basket.Money, now time.Time) basket.Money {
+	if c.validate(amount, now) != nil {
+		return basket.NewMoney(0, amount.Currency)
+	}
+	discount := basket.NewMoney(c.Amount.Amount, amount.Currency)
+	if c.Percent > 0 {
+		discount = amount.Percent(c.Percent)
+	}
+	if discount.Amount > amount.Amount {
+		return amount
+	}
+	return discount
+}
+
+func (c Coupon) validate(amount basket.Money, now time.Time) error {
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return ErrCouponExpired
+	}
+	if amount.Amount < c.MinAmount.Amount {
+		return ErrMinAmountNotReached
+	}
+	return nil
+}
+
+// Registry keeps the coupons and counts their redemptions. It is safe for concurrent use.
+type Registry struct {
+	mutex       sync.Mutex
+	coupons     map[string]Coupon
+	redemptions map[string]int
+}
+
+// NewRegistry validates the configuration and returns its Registry.
+func NewRegistry(cfg Config) (*Registry, error) {
+	coupons := make(map[string]Coupon, len(cfg.Coupons))
+	for _, def := range cfg.Coupons {
+		c, err := def.Build()
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := coupons[c.Code]; exists {
+			return nil, fmt.Errorf("%w: duplicated code %q", ErrInvalidCoupon, c.Code)
+		}
+		coupons[c.Code] = c
+	}
+	return &Registry{coupons: coupons, redemptions: make(map[string]int)}, nil
+}
+
+// Get returns the coupon of the code, codes are case insensitive.
+func (r *Registry) Get(code string) (Coupon, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	c, exists := r.coupons[normalize(code)]
+	return c, exists
+}
+
+// Check validates the coupon for a basket of the given amount, the redemption is only counted by Redeem.
+func (r *Registry) Check(code string, amount basket.Money, now time.Time) (Coupon, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	c, exists := r.coupons[normalize(code)]
+	if !exists {
+		return Coupon{}, ErrCouponNotFound
+	}
+	if err := c.validate(amount, now); err != nil {
+		return Coupon{}, err
+	}
+	if c.MaxRedemptions > 0 && r.redemptions[c.Code] >= c.MaxRedemptions {
+		return Coupon{}, ErrCouponExhausted
+	}
+	return c, nil
+}
+
+// Redeem counts one redemption of the coupon, used when the basket that has it is paid,
+// so the baskets left unpaid do not use up the coupon.
+func (r *Registry) Redeem(code string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	c, exists := r.coupons[normalize(code)]
+	if !exists {
+		return ErrCouponNotFound
+	}
+	if c.MaxRedemptions > 0 && r.redemptions[c.Code] >= c.MaxRedemptions {
+		return ErrCouponExhausted
+	}
+	r.redemptions[c.Code]++
+	return nil
+}
+
+func normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
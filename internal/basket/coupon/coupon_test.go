@@ -0,0 +1,132 @@
+package coupon
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinitionBuild(t *testing.T) {
+	amount := basket.NewMoney(500, "")
+	negative := basket.NewMoney(-1, "")
+
+	tests := []struct {
+		name        string
+		definition  Definition
+		expected    Coupon
+		expectedErr error
+	}{
+		{
+			name:       "Build - Percentage",
+			definition: Definition{Code: "lana10", Type: TypePercentage, DiscountPercent: 12.5},
+			expected:   Coupon{Code: "LANA10", Percent: 1250},
+		},
+		{
+			name:       "Build - Fixed",
+			definition: Definition{Code: "FIVE", Type: TypeFixed, Amount: &amount, MaxRedemptions: 3},
+			expected:   Coupon{Code: "FIVE", Amount: amount, MaxRedemptions: 3},
+		},
+		{
+			name:        "Build - Missing code",
+			definition:  Definition{Type: TypePercentage, DiscountPercent: 10},
+			expectedErr: ErrInvalidCoupon,
+		},
+		{
+			name:        "Build - Percentage out of range",
+			definition:  Definition{Code: "BIG", Type: TypePercentage, DiscountPercent: 150},
+			expectedErr: ErrInvalidCoupon,
+		},
+		{
+			name:        "Build - Percentage with more than two decimals",
+			definition:  Definition{Code: "ODD", Type: TypePercentage, DiscountPercent: 12.345},
+			expectedErr: ErrInvalidCoupon,
+		},
+		{
+			name:        "Build - Fixed without amount",
+			definition:  Definition{Code: "FIVE", Type: TypeFixed},
+			expectedErr: ErrInvalidCoupon,
+		},
+		{
+			name:        "Build - Negative min amount",
+			definition:  Definition{Code: "FIVE", Type: TypeFixed, Amount: &amount, MinAmount: &negative},
+			expectedErr: ErrInvalidCoupon,
+		},
+		{
+			name:        "Build - Unknown type",
+			definition:  Definition{Code: "FREE", Type: "free"},
+			expectedErr: ErrInvalidCoupon,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.definition.Build()
+			require.True(t, errors.Is(err, tt.expectedErr))
+			require.Equal(t, tt.expected, c)
+		})
+	}
+}
+
+func TestCouponDiscount(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		amount   int64
+		expected int64
+	}{
+		{name: "Discount - Percentage", coupon: Coupon{Percent: 1000}, amount: 6250, expected: 625},
+		{name: "Discount - Fixed", coupon: Coupon{Amount: eur(500)}, amount: 2000, expected: 500},
+		{name: "Discount - Fixed capped at the amount", coupon: Coupon{Amount: eur(500)}, amount: 300, expected: 300},
+		{name: "Discount - Below the minimum", coupon: Coupon{Amount: eur(500), MinAmount: eur(2000)}, amount: 1999, expected: 0},
+		{name: "Discount - Expired", coupon: Coupon{Percent: 1000, ExpiresAt: &expired}, amount: 2000, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, eur(tt.expected), tt.coupon.Discount(eur(tt.amount), now))
+		})
+	}
+}
+
+func TestRegistryRedeem(t *testing.T) {
+	now := time.Now()
+	registry, err := NewRegistry(Config{Coupons: []Definition{
+		{Code: "ONCE", Type: TypePercentage, DiscountPercent: 10, MaxRedemptions: 1},
+	}})
+	require.NoError(t, err)
+
+	amount := basket.NewMoney(1000, basket.DefaultCurrency)
+	// Checking does not count a redemption.
+	_, err = registry.Check("once", amount, now)
+	require.NoError(t, err)
+	_, err = registry.Check("ONCE", amount, now)
+	require.NoError(t, err)
+
+	require.NoError(t, registry.Redeem("once"))
+	require.Equal(t, ErrCouponExhausted, registry.Redeem("ONCE"))
+	_, err = registry.Check("ONCE", amount, now)
+	require.Equal(t, ErrCouponExhausted, err)
+
+	_, err = registry.Check("RANDOM", amount, now)
+	require.Equal(t, ErrCouponNotFound, err)
+	require.Equal(t, ErrCouponNotFound, registry.Redeem("RANDOM"))
+
+	_, err = NewRegistry(Config{Coupons: []Definition{
+		{Code: "DUP", Type: TypePercentage, DiscountPercent: 10},
+		{Code: "dup", Type: TypePercentage, DiscountPercent: 20},
+	}})
+	require.True(t, errors.Is(err, ErrInvalidCoupon))
+}
+
+func TestLoadConfigRepositoryFile(t *testing.T) {
+	cfg, err := LoadConfig("../../../config/coupons.json")
+	require.NoError(t, err)
+	require.Equal(t, DefaultConfig(), cfg)
+}
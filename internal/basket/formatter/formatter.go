@@ -0,0 +1,119 @@
+package formatter
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/currency"
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/it"
+	"github.com/go-playground/locales/nl"
+	"github.com/go-playground/locales/pt"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+)
+
+var currencies = map[string]currency.Type{
+	"ARS": currency.ARS,
+	"BRL": currency.BRL,
+	"CLP": currency.CLP,
+	"EUR": currency.EUR,
+	"GBP": currency.GBP,
+	"JPY": currency.JPY,
+	"MXN": currency.MXN,
+	"USD": currency.USD,
+}
+
+// symbols replace the ISO code of the currencies some locales render without a symbol, like "EUR10.00" in english.
+var symbols = map[string]string{
+	"EUR": "€",
+	"GBP": "£",
+}
+
+// MoneyFormatter formats money using the CLDR data of the supported locales.
+type MoneyFormatter struct {
+	translator *ut.UniversalTranslator
+}
+
+// New returns a MoneyFormatter that falls back to english when no requested locale is supported.
+func New() *MoneyFormatter {
+	fallback := en.New()
+	return &MoneyFormatter{
+		translator: ut.New(fallback, fallback, es.New(), fr.New(), de.New(), it.New(), nl.New(), pt.New()),
+	}
+}
+
+// Format returns the amount formatted for the first supported locale of the list, e.g. "62,50 €" for "es".
+// Locales are tried in order and also by their base language, so "es-AR" matches "es".
+func (f *MoneyFormatter) Format(money basket.Money, locales ...string) string {
+	trans, _ := f.translator.FindTranslator(candidates(locales)...)
+
+	cur, ok := currencies[money.Currency]
+	if !ok {
+		return money.String() + " " + money.Currency
+	}
+	// The float conversion is only used to render the digits, amounts are exact up to 2^53 minor units.
+	num := float64(money.Amount) / math.Pow10(money.Decimals())
+	formatted := strings.TrimSpace(trans.FmtCurrency(num, uint64(money.Decimals()), cur))
+	if symbol, ok := symbols[money.Currency]; ok {
+		formatted = strings.Replace(formatted, money.Currency, symbol, 1)
+	}
+	return formatted
+}
+
+// ParseAcceptLanguage returns the language tags of an Accept-Language header sorted by quality.
+func ParseAcceptLanguage(header string) []string {
+	type tag struct {
+		name    string
+		quality float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.TrimSpace(fields[0])
+		if name == "" || name == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			tags = append(tags, tag{name: name, quality: quality})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.name)
+	}
+	return names
+}
+
+// candidates normalizes BCP 47 tags to the locales naming ("es-AR" to "es_AR")
+// adding the base language after each one.
+func candidates(locales []string) []string {
+	result := make([]string, 0, len(locales)*2)
+	for _, locale := range locales {
+		locale = strings.ReplaceAll(strings.TrimSpace(locale), "-", "_")
+		if locale == "" {
+			continue
+		}
+		result = append(result, locale)
+		if i := strings.IndexByte(locale, '_'); i > 0 {
+			result = append(result, locale[:i])
+		}
+	}
+	return result
+}
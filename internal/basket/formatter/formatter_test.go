@@ -0,0 +1,65 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	moneyFormatter := New()
+	tests := []struct {
+		name     string
+		money    basket.Money
+		locales  []string
+		expected string
+	}{
+		{
+			name:     "Format - Spanish",
+			money:    basket.NewMoney(6250, basket.DefaultCurrency),
+			locales:  []string{"es"},
+			expected: "62,50\u00a0€",
+		},
+		{
+			name:     "Format - Region falls back to base language",
+			money:    basket.NewMoney(6250, basket.DefaultCurrency),
+			locales:  []string{"fr-CA"},
+			expected: "62,50\u00a0€",
+		},
+		{
+			name:     "Format - First supported locale wins",
+			money:    basket.NewMoney(6250, basket.DefaultCurrency),
+			locales:  []string{"zz", "pt-BR", "es"},
+			expected: "€62,50",
+		},
+		{
+			name:     "Format - Fallback english with the symbol of the euro",
+			money:    basket.NewMoney(-6250, basket.DefaultCurrency),
+			expected: "-€62.50",
+		},
+		{
+			name:     "Format - Fallback english",
+			money:    basket.NewMoney(-123450, "USD"),
+			expected: "-$1,234.50",
+		},
+		{
+			name:     "Format - Unknown currency",
+			money:    basket.NewMoney(6250, "XYZ"),
+			locales:  []string{"es"},
+			expected: "62.50 XYZ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, moneyFormatter.Format(tt.money, tt.locales...))
+		})
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	require.Equal(t, []string{"fr-CH", "fr", "en", "de"}, ParseAcceptLanguage("fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5"))
+	require.Equal(t, []string{"es", "en"}, ParseAcceptLanguage("en;q=0.5, es, it;q=0"))
+	require.Empty(t, ParseAcceptLanguage(""))
+}
@@ -0,0 +1,65 @@
+package local_map
+
+import (
+	"fmt"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+)
+
+// Products returns the products of the catalog that can be added to a basket.
+func (s *Service) Products() []basket.Product {
+	return s.catalog.List()
+}
+
+// ProductsByCategory returns the products of the catalog whose category or tags include the category.
+func (s *Service) ProductsByCategory(category string) []basket.Product {
+	products := []basket.Product{}
+	for _, product := range s.catalog.List() {
+		if catalog.InCategory(product, category) {
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+// Product returns the product of the code.
+func (s *Service) Product(code string) (basket.Product, error) {
+	return s.catalog.Get(code)
+}
+
+// CreateProduct adds the product to the catalog.
+func (s *Service) CreateProduct(product basket.Product) (basket.Product, error) {
+	s.prmMutex.Lock()
+	defer s.prmMutex.Unlock()
+	if err := s.checkPromotions(product); err != nil {
+		return basket.Product{}, err
+	}
+	return s.catalog.Create(product)
+}
+
+// UpdateProduct replaces the product of the code, the baskets with it are repriced when read.
+// The change is rejected when the promotions in use would not be valid with it.
+func (s *Service) UpdateProduct(code string, product basket.Product) (basket.Product, error) {
+	s.prmMutex.Lock()
+	defer s.prmMutex.Unlock()
+	if err := s.checkPromotions(product); err != nil {
+		return basket.Product{}, err
+	}
+	return s.catalog.Update(code, product)
+}
+
+// checkPromotions checks the promotions in use are still valid with the product in the catalog. The caller holds prmMutex.
+func (s *Service) checkPromotions(product basket.Product) error {
+	products := s.catalog.Prices()
+	products[product.Code] = product
+	if _, err := buildPromotionConfig(s.promotionCfg, products); err != nil {
+		return fmt.Errorf("%w: %q: %s", catalog.ErrInvalidProduct, product.Code, err.Error())
+	}
+	return nil
+}
+
+// DeleteProduct removes the product from the catalog, the baskets that already have it keep its price.
+func (s *Service) DeleteProduct(code string) error {
+	return s.catalog.Delete(code)
+}
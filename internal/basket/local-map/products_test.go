@@ -0,0 +1,123 @@
+package local_map
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManageProducts(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+
+	_, err = service.CreateProduct(basket.Product{Code: "CAP", Name: "Lana Cap", Price: eur(1200)})
+	require.NoError(t, err)
+	require.Len(t, service.Products(), 4)
+
+	bkt := service.Create()
+	_, err = service.AddProduct(bkt.ID, "CAP", 1, 0)
+	require.NoError(t, err)
+	bkt, err = service.AddProduct(bkt.ID, lanaMugCode, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, eur(1950), bkt.Amount)
+
+	// A price change is seen when the basket is read.
+	_, err = service.UpdateProduct("CAP", basket.Product{Code: "CAP", Name: "Lana Cap", Price: eur(1000)})
+	require.NoError(t, err)
+	amount, err := service.GetAmount(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, eur(1750), amount.Amount)
+
+	// A deleted product can not be added, but the baskets that have it keep pricing it.
+	require.NoError(t, service.DeleteProduct("CAP"))
+	_, err = service.Product("CAP")
+	require.Equal(t, catalog.ErrProductNotFound, err)
+	_, err = service.AddProduct(bkt.ID, "CAP", 1, 0)
+	require.Equal(t, ErrInvalidProductCode, err)
+	bkt, err = service.AddProduct(bkt.ID, lanaMugCode, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, eur(2500), bkt.Amount)
+	require.Len(t, service.Products(), 3)
+}
+
+func TestRepricingChangesVersion(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	bkt := service.Create()
+	bkt, err = service.AddProduct(bkt.ID, lanaMugCode, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, bkt.Version)
+
+	// Reading the basket without a new price keeps its version.
+	got, err := service.Get(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, got.Version)
+
+	mug, err := service.Product(lanaMugCode)
+	require.NoError(t, err)
+	mug.Price = basket.NewMoney(900, basket.DefaultCurrency)
+	_, err = service.UpdateProduct(lanaMugCode, mug)
+	require.NoError(t, err)
+
+	// The version read before the new price is stale, even if nobody read the basket since.
+	_, err = service.AddProduct(bkt.ID, lanaMugCode, 1, 2)
+	require.Equal(t, ErrVersionMismatch, err)
+	amount, err := service.GetAmount(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, 3, amount.Version)
+	got, err = service.Get(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, 3, got.Version)
+	require.Equal(t, basket.NewMoney(900, basket.DefaultCurrency), got.Amount)
+	_, err = service.AddProduct(bkt.ID, lanaMugCode, 1, 3)
+	require.NoError(t, err)
+}
+
+func TestUpdateProductKeepsPromotionsValid(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+
+	// pen-2x1 targets PEN, which can not become a variant.
+	pen, err := service.Product(lanaPenCode)
+	require.NoError(t, err)
+	pen.Parent = lanaMugCode
+	_, err = service.UpdateProduct(lanaPenCode, pen)
+	require.True(t, errors.Is(err, catalog.ErrInvalidProduct))
+	pen, err = service.Product(lanaPenCode)
+	require.NoError(t, err)
+	require.Empty(t, pen.Parent)
+
+	// The promotions can still be changed.
+	_, err = service.EnablePromotion("pen-2x1", false)
+	require.NoError(t, err)
+}
+
+func TestProductsByCategory(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+	_, err = service.CreateProduct(basket.Product{Code: "BOTTLE", Name: "Lana Bottle", Price: eur(1000), Category: "outdoor", Tags: []string{"drinkware"}})
+	require.NoError(t, err)
+
+	require.Equal(t, []basket.Product{productMap[lanaPenCode]}, service.ProductsByCategory("office"))
+	drinkware := service.ProductsByCategory("drinkware")
+	require.Len(t, drinkware, 2)
+	require.Equal(t, "BOTTLE", drinkware[0].Code)
+	require.Equal(t, []basket.Product{}, service.ProductsByCategory("garden"))
+
+	_, err = service.CreatePromotion(promotion.Definition{
+		Name: "drinkware-20", Type: promotion.TypeBuyXOrMore, Categories: []string{"drinkware"}, MinQuantity: 1, DiscountPercent: 20,
+	})
+	require.NoError(t, err)
+	bkt := service.Create()
+	_, err = service.AddProduct(bkt.ID, lanaMugCode, 1, 0)
+	require.NoError(t, err)
+	bkt, err = service.AddProduct(bkt.ID, "BOTTLE", 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, eur(1400), bkt.Amount)
+}
@@ -0,0 +1,123 @@
+package local_map
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+)
+
+var (
+	// ErrPromotionNotFound is used when the promotion name is not found on the configuration.
+	ErrPromotionNotFound = errors.New("promotion not found")
+)
+
+// currentPricing returns the promotions and the catalog prices in use, read together under prmMutex
+// so a reload never leaves a basket priced with the promotions of one catalog and the prices of another.
+// The set is never modified once built.
+func (s *Service) currentPricing() (promotion.Set, map[string]basket.Product) {
+	s.prmMutex.RLock()
+	defer s.prmMutex.RUnlock()
+	return s.promotions, s.catalog.Prices()
+}
+
+// Promotions returns the definitions of the promotions, the disabled ones included.
+func (s *Service) Promotions() []promotion.Definition {
+	s.prmMutex.RLock()
+	defer s.prmMutex.RUnlock()
+	return append([]promotion.Definition{}, s.promotionCfg.Promotions...)
+}
+
+// Promotion returns the definition of the promotion with the name.
+func (s *Service) Promotion(name string) (promotion.Definition, error) {
+	s.prmMutex.RLock()
+	defer s.prmMutex.RUnlock()
+	i := s.promotionCfg.Find(name)
+	if i < 0 {
+		return promotion.Definition{}, ErrPromotionNotFound
+	}
+	return s.promotionCfg.Promotions[i], nil
+}
+
+// CreatePromotion validates the promotion and adds it to the ones in use.
+func (s *Service) CreatePromotion(def promotion.Definition) (promotion.Definition, error) {
+	s.prmMutex.Lock()
+	defer s.prmMutex.Unlock()
+	if err := s.promotionCfg.Conflict(def); err != nil {
+		return promotion.Definition{}, err
+	}
+	cfg := promotion.Config{Promotions: append(s.copyPromotions(), def)}
+	if err := s.swapPromotions(cfg); err != nil {
+		return promotion.Definition{}, err
+	}
+	return def, nil
+}
+
+// UpdatePromotion replaces the promotion with the name, the definition can not rename it.
+func (s *Service) UpdatePromotion(name string, def promotion.Definition) (promotion.Definition, error) {
+	s.prmMutex.Lock()
+	defer s.prmMutex.Unlock()
+	if def.Name != name {
+		return promotion.Definition{}, fmt.Errorf("%w: name %q does not match %q", promotion.ErrInvalidPromotion, def.Name, name)
+	}
+	return s.replacePromotion(def)
+}
+
+// EnablePromotion enables or disables the promotion with the name.
+func (s *Service) EnablePromotion(name string, enabled bool) (promotion.Definition, error) {
+	s.prmMutex.Lock()
+	defer s.prmMutex.Unlock()
+	i := s.promotionCfg.Find(name)
+	if i < 0 {
+		return promotion.Definition{}, ErrPromotionNotFound
+	}
+	def := s.promotionCfg.Promotions[i]
+	def.Disabled = !enabled
+	return s.replacePromotion(def)
+}
+
+// DeletePromotion removes the promotion with the name.
+func (s *Service) DeletePromotion(name string) error {
+	s.prmMutex.Lock()
+	defer s.prmMutex.Unlock()
+	i := s.promotionCfg.Find(name)
+	if i < 0 {
+		return ErrPromotionNotFound
+	}
+	definitions := s.copyPromotions()
+	return s.swapPromotions(promotion.Config{Promotions: append(definitions[:i], definitions[i+1:]...)})
+}
+
+// replacePromotion replaces the promotion with the same name as def. The caller holds prmMutex.
+func (s *Service) replacePromotion(def promotion.Definition) (promotion.Definition, error) {
+	i := s.promotionCfg.Find(def.Name)
+	if i < 0 {
+		return promotion.Definition{}, ErrPromotionNotFound
+	}
+	definitions := s.copyPromotions()
+	others := promotion.Config{Promotions: append(append([]promotion.Definition{}, definitions[:i]...), definitions[i+1:]...)}
+	if err := others.Conflict(def); err != nil {
+		return promotion.Definition{}, err
+	}
+	definitions[i] = def
+	if err := s.swapPromotions(promotion.Config{Promotions: definitions}); err != nil {
+		return promotion.Definition{}, err
+	}
+	return def, nil
+}
+
+// swapPromotions builds the configuration and puts it in use. The caller holds prmMutex.
+func (s *Service) swapPromotions(cfg promotion.Config) error {
+	set, err := buildPromotionConfig(cfg, s.catalog.Prices())
+	if err != nil {
+		return err
+	}
+	s.promotionCfg = cfg
+	s.promotions = set
+	return nil
+}
+
+func (s *Service) copyPromotions() []promotion.Definition {
+	return append([]promotion.Definition{}, s.promotionCfg.Promotions...)
+}
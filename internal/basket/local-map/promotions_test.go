@@ -0,0 +1,103 @@
+package local_map
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagePromotions(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	mug2x1 := promotion.Definition{Name: "mug-2x1", Type: promotion.TypeBuyNPayM, Products: []string{lanaMugCode}, Buy: 2, Pay: 1}
+	mugAmount := func() int64 {
+		bkt := service.Create()
+		bkt, err := service.AddProduct(bkt.ID, lanaMugCode, 2, 0)
+		require.NoError(t, err)
+		return bkt.Amount.Amount
+	}
+
+	require.Equal(t, promotion.DefaultConfig().Promotions, service.Promotions())
+	require.Equal(t, int64(1500), mugAmount())
+
+	_, err = service.CreatePromotion(mug2x1)
+	require.NoError(t, err)
+	require.Equal(t, int64(750), mugAmount())
+	def, err := service.Promotion("mug-2x1")
+	require.NoError(t, err)
+	require.Equal(t, mug2x1, def)
+
+	_, err = service.CreatePromotion(mug2x1)
+	require.True(t, errors.Is(err, promotion.ErrPromotionConflict))
+	_, err = service.CreatePromotion(promotion.Definition{Name: "mug-3x2", Type: promotion.TypeBuyNPayM, Products: []string{lanaMugCode}, Buy: 3, Pay: 2})
+	require.True(t, errors.Is(err, promotion.ErrPromotionConflict))
+	_, err = service.CreatePromotion(promotion.Definition{Name: "random", Type: promotion.TypeBuyNPayM, Products: []string{"RANDOM"}, Buy: 2, Pay: 1})
+	require.True(t, errors.Is(err, promotion.ErrInvalidPromotion))
+
+	updated := mug2x1
+	updated.Buy, updated.Pay = 2, 1
+	updated.MaxApplications = 1
+	_, err = service.UpdatePromotion("mug-2x1", updated)
+	require.NoError(t, err)
+	_, err = service.UpdatePromotion("mug-2x1", promotion.Definition{Name: "other", Type: promotion.TypeBuyNPayM, Products: []string{lanaMugCode}, Buy: 2, Pay: 1})
+	require.True(t, errors.Is(err, promotion.ErrInvalidPromotion))
+	_, err = service.UpdatePromotion("random", promotion.Definition{Name: "random", Type: promotion.TypeBuyNPayM, Products: []string{lanaMugCode}, Buy: 2, Pay: 1})
+	require.Equal(t, ErrPromotionNotFound, err)
+
+	def, err = service.EnablePromotion("mug-2x1", false)
+	require.NoError(t, err)
+	require.True(t, def.Disabled)
+	require.Equal(t, int64(1500), mugAmount())
+	require.Len(t, service.Promotions(), 3)
+
+	_, err = service.EnablePromotion("mug-2x1", true)
+	require.NoError(t, err)
+	require.Equal(t, int64(750), mugAmount())
+
+	require.NoError(t, service.DeletePromotion("mug-2x1"))
+	require.Equal(t, int64(1500), mugAmount())
+	require.Equal(t, ErrPromotionNotFound, service.DeletePromotion("mug-2x1"))
+	_, err = service.Promotion("mug-2x1")
+	require.Equal(t, ErrPromotionNotFound, err)
+}
+
+func TestSwapPromotionsConcurrently(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	bkt := service.Create()
+	mug2x1 := promotion.Definition{Name: "mug-2x1", Type: promotion.TypeBuyNPayM, Products: []string{lanaMugCode}, Buy: 2, Pay: 1}
+	_, err = service.CreatePromotion(mug2x1)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if _, err := service.EnablePromotion("mug-2x1", i%2 == 0); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if _, err := service.AddProduct(bkt.ID, lanaMugCode, 1, 0); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	bkt, err = service.Get(bkt.ID)
+	require.NoError(t, err)
+	// Whatever the last set seen, the basket is priced with the whole 2x1 or without it.
+	require.Contains(t, []basket.Money{
+		basket.NewMoney(50*750, basket.DefaultCurrency),
+		basket.NewMoney(25*750, basket.DefaultCurrency),
+	}, bkt.Amount)
+}
@@ -0,0 +1,153 @@
+package local_map
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+)
+
+// ReloadDiff lists the codes of the products and the names of the promotions changed by a reload.
+type ReloadDiff struct {
+	ProductsAdded     []string
+	ProductsRemoved   []string
+	ProductsChanged   []string
+	PromotionsAdded   []string
+	PromotionsRemoved []string
+	PromotionsChanged []string
+}
+
+// Empty reports whether the reload did not change anything.
+func (d ReloadDiff) Empty() bool {
+	return len(d.ProductsAdded)+len(d.ProductsRemoved)+len(d.ProductsChanged)+
+		len(d.PromotionsAdded)+len(d.PromotionsRemoved)+len(d.PromotionsChanged) == 0
+}
+
+func (d ReloadDiff) String() string {
+	if d.Empty() {
+		return "no changes"
+	}
+	var changes []string
+	for _, group := range []struct {
+		name  string
+		items []string
+	}{
+		{"products added", d.ProductsAdded},
+		{"products removed", d.ProductsRemoved},
+		{"products changed", d.ProductsChanged},
+		{"promotions added", d.PromotionsAdded},
+		{"promotions removed", d.PromotionsRemoved},
+		{"promotions changed", d.PromotionsChanged},
+	} {
+		if len(group.items) > 0 {
+			changes = append(changes, fmt.Sprintf("%s: %s", group.name, strings.Join(group.items, ", ")))
+		}
+	}
+	return strings.Join(changes, "; ")
+}
+
+// Reload reads the catalog and promotions files again and puts them in use once both are valid.
+// When any of them fails the configuration in use is kept. The promotions created with the admin API
+// are replaced by the ones of the file, the ones in use are validated against the new catalog otherwise.
+// Both are swapped under prmMutex, so a basket is priced with the old configuration or the new one, never a mix.
+func (s *Service) Reload() (ReloadDiff, error) {
+	s.prmMutex.Lock()
+	defer s.prmMutex.Unlock()
+
+	current := s.catalog.List()
+	products := current
+	prices := s.catalog.Prices()
+	if s.catalogFile != "" {
+		var err error
+		if products, err = catalog.LoadFile(s.catalogFile); err != nil {
+			return ReloadDiff{}, err
+		}
+		prices = make(map[string]basket.Product, len(products))
+		for _, product := range products {
+			prices[product.Code] = product
+		}
+	}
+	cfg := s.promotionCfg
+	if s.promotionsFile != "" {
+		var err error
+		if cfg, err = promotion.LoadConfig(s.promotionsFile); err != nil {
+			return ReloadDiff{}, err
+		}
+	}
+	set, err := buildPromotionConfig(cfg, prices)
+	if err != nil {
+		return ReloadDiff{}, err
+	}
+
+	if s.catalogFile != "" {
+		if err := s.catalog.Replace(products); err != nil {
+			return ReloadDiff{}, err
+		}
+		// The catalog keeps the stock left of its products, the diff is against what it has now.
+		products = s.catalog.List()
+	}
+	diff := ReloadDiff{}
+	diff.ProductsAdded, diff.ProductsRemoved, diff.ProductsChanged = diffProducts(current, products)
+	diff.PromotionsAdded, diff.PromotionsRemoved, diff.PromotionsChanged = diffPromotions(s.promotionCfg.Promotions, cfg.Promotions)
+	s.promotionCfg = cfg
+	s.promotions = set
+	return diff, nil
+}
+
+func diffProducts(before, after []basket.Product) (added, removed, changed []string) {
+	previous := make(map[string]basket.Product, len(before))
+	for _, product := range before {
+		previous[product.Code] = product
+	}
+	next := make(map[string]bool, len(after))
+	for _, product := range after {
+		next[product.Code] = true
+		old, exists := previous[product.Code]
+		switch {
+		case !exists:
+			added = append(added, product.Code)
+		case !reflect.DeepEqual(old, product):
+			changed = append(changed, product.Code)
+		}
+	}
+	for code := range previous {
+		if !next[code] {
+			removed = append(removed, code)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+func diffPromotions(before, after []promotion.Definition) (added, removed, changed []string) {
+	previous := make(map[string]promotion.Definition, len(before))
+	for _, def := range before {
+		previous[def.Name] = def
+	}
+	next := make(map[string]bool, len(after))
+	for _, def := range after {
+		next[def.Name] = true
+		old, exists := previous[def.Name]
+		switch {
+		case !exists:
+			added = append(added, def.Name)
+		case !reflect.DeepEqual(old, def):
+			changed = append(changed, def.Name)
+		}
+	}
+	for name := range previous {
+		if !next[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
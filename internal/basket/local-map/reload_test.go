@@ -0,0 +1,191 @@
+package local_map
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	catalogFile := filepath.Join(dir, "catalog.csv")
+	promotionsFile := filepath.Join(dir, "promotions.json")
+	write := func(path, content string) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+	write(catalogFile, "code,name,price\nPEN,Lana Pen,5\nMUG,Lana Coffee Mug,7.5\n")
+	write(promotionsFile, `{"promotions": [{"name": "pen-2x1", "type": "buy_n_pay_m", "products": ["PEN"], "buy": 2, "pay": 1}]}`)
+
+	service, err := New(WithCatalogFile(catalogFile), WithPromotionsFile(promotionsFile))
+	require.NoError(t, err)
+	bkt := service.Create()
+	bkt, err = service.AddProduct(bkt.ID, lanaMugCode, 2, 0)
+	require.NoError(t, err)
+	require.Equal(t, basket.NewMoney(1500, basket.DefaultCurrency), bkt.Amount)
+
+	tests := []struct {
+		name         string
+		catalog      string
+		promotions   string
+		expectedDiff ReloadDiff
+		expectedErr  error
+	}{
+		{
+			name:         "Reload - Ok - No changes",
+			catalog:      "code,name,price\nPEN,Lana Pen,5\nMUG,Lana Coffee Mug,7.5\n",
+			promotions:   `{"promotions": [{"name": "pen-2x1", "type": "buy_n_pay_m", "products": ["PEN"], "buy": 2, "pay": 1}]}`,
+			expectedDiff: ReloadDiff{},
+		},
+		{
+			name:        "Reload - Error - Invalid catalog",
+			catalog:     "code,name,price\nPEN,Lana Pen,5\nMUG,,7.5\n",
+			promotions:  `{"promotions": []}`,
+			expectedErr: catalog.ErrInvalidCatalog,
+		},
+		{
+			name:        "Reload - Error - Promotion of a removed product",
+			catalog:     "code,name,price\nMUG,Lana Coffee Mug,7.5\n",
+			promotions:  `{"promotions": [{"name": "pen-2x1", "type": "buy_n_pay_m", "products": ["PEN"], "buy": 2, "pay": 1}]}`,
+			expectedErr: promotion.ErrInvalidPromotion,
+		},
+		{
+			name:       "Reload - Ok - Changes",
+			catalog:    "code,name,price\nMUG,Lana Coffee Mug,8\nCAP,Lana Cap,12.5\n",
+			promotions: `{"promotions": [{"name": "mug-2x1", "type": "buy_n_pay_m", "products": ["MUG"], "buy": 2, "pay": 1}]}`,
+			expectedDiff: ReloadDiff{
+				ProductsAdded:     []string{"CAP"},
+				ProductsRemoved:   []string{"PEN"},
+				ProductsChanged:   []string{"MUG"},
+				PromotionsAdded:   []string{"mug-2x1"},
+				PromotionsRemoved: []string{"pen-2x1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write(catalogFile, tt.catalog)
+			write(promotionsFile, tt.promotions)
+			diff, err := service.Reload()
+			if tt.expectedErr != nil {
+				require.True(t, errors.Is(err, tt.expectedErr))
+				require.Len(t, service.Products(), 2)
+				require.Equal(t, "pen-2x1", service.Promotions()[0].Name)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedDiff, diff)
+		})
+	}
+
+	bkt, err = service.Get(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, basket.NewMoney(800, basket.DefaultCurrency), bkt.Amount)
+	_, err = service.AddProduct(bkt.ID, lanaPenCode, 1, 0)
+	require.Error(t, err)
+}
+
+func TestReloadAfterPayment(t *testing.T) {
+	dir := t.TempDir()
+	catalogFile := filepath.Join(dir, "catalog.csv")
+	require.NoError(t, ioutil.WriteFile(catalogFile, []byte("code,name,price,stock\nPEN,Lana Pen,5,5\n"), 0600))
+	promotionsFile := filepath.Join(dir, "promotions.json")
+	require.NoError(t, ioutil.WriteFile(promotionsFile, []byte(`{"promotions": []}`), 0600))
+	service, err := New(WithCatalogFile(catalogFile), WithPromotionsFile(promotionsFile))
+	require.NoError(t, err)
+
+	paid := service.Create()
+	_, err = service.AddProduct(paid.ID, lanaPenCode, 5, 0)
+	require.NoError(t, err)
+	_, err = service.Checkout(paid.ID, 0)
+	require.NoError(t, err)
+	_, err = service.Pay(paid.ID, 0)
+	require.NoError(t, err)
+
+	// The units sold are not put back by the file.
+	diff, err := service.Reload()
+	require.NoError(t, err)
+	require.True(t, diff.Empty())
+	pen, err := service.Product(lanaPenCode)
+	require.NoError(t, err)
+	require.Equal(t, 0, *pen.Stock)
+	bkt := service.Create()
+	_, err = service.AddProduct(bkt.ID, lanaPenCode, 1, 0)
+	require.True(t, errors.Is(err, ErrOutOfStock))
+}
+
+func TestReloadWhilePricing(t *testing.T) {
+	dir := t.TempDir()
+	catalogFile := filepath.Join(dir, "catalog.csv")
+	promotionsFile := filepath.Join(dir, "promotions.json")
+	write := func(path, content string) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+	// 2 pens are 5€ with the first configuration and 20€ with the second one, 10€ when they are mixed.
+	configs := []struct {
+		catalog    string
+		promotions string
+	}{
+		{
+			catalog:    "code,name,price\nPEN,Lana Pen,5\n",
+			promotions: `{"promotions": [{"name": "pen-2x1", "type": "buy_n_pay_m", "products": ["PEN"], "buy": 2, "pay": 1}]}`,
+		},
+		{
+			catalog:    "code,name,price\nPEN,Lana Pen,10\n",
+			promotions: `{"promotions": []}`,
+		},
+	}
+	write(catalogFile, configs[0].catalog)
+	write(promotionsFile, configs[0].promotions)
+	service, err := New(WithCatalogFile(catalogFile), WithPromotionsFile(promotionsFile))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 200; i++ {
+			config := configs[i%len(configs)]
+			// FailNow can not be called out of the test goroutine.
+			if err := ioutil.WriteFile(catalogFile, []byte(config.catalog), 0600); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := ioutil.WriteFile(promotionsFile, []byte(config.promotions), 0600); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := service.Reload(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	items := []basket.QuoteItem{{Code: lanaPenCode, Quantity: 2}}
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		quote, err := service.Quote(items, nil, time.Time{})
+		require.NoError(t, err)
+		require.Contains(t, []basket.Money{
+			basket.NewMoney(500, basket.DefaultCurrency),
+			basket.NewMoney(2000, basket.DefaultCurrency),
+		}, quote.Amount)
+	}
+}
+
+func TestReloadDiffString(t *testing.T) {
+	require.Equal(t, "no changes", ReloadDiff{}.String())
+	require.Equal(t, "products added: CAP, HAT; promotions removed: pen-2x1",
+		ReloadDiff{ProductsAdded: []string{"CAP", "HAT"}, PromotionsRemoved: []string{"pen-2x1"}}.String())
+}
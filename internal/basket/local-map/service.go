@@ -2,10 +2,15 @@ package local_map
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+	"github.com/mercadolibre/backend-challenge/internal/basket/coupon"
 	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
 	"github.com/rs/xid"
 )
@@ -14,19 +19,17 @@ const (
 	lanaPenCode    = "PEN"
 	lanaTshirtCode = "TSHIRT"
 	lanaMugCode    = "MUG"
-	statusActive   = "active"
-	statusInactive = "inactive"
+	dateLayout     = "01-02-2006 15:04:05"
+	discountCoupon = "coupon"
+	// maxQuantity bounds the units of a product in a basket or a quote, so pricing them stays cheap.
+	maxQuantity = 1000
 )
 
 var (
 	productMap = map[string]basket.Product{
-		lanaPenCode:    {Code: lanaPenCode, Name: "Lana Pen", Price: 5.00},
-		lanaTshirtCode: {Code: lanaTshirtCode, Name: "Lana T-Shirt", Price: 20.00},
-		lanaMugCode:    {Code: lanaMugCode, Name: "Lana Coffee Mug ", Price: 7.50},
-	}
-	promotionMap = map[string]Promotion{
-		lanaPenCode:    &promotion.Buy2Get1Free{},
-		lanaTshirtCode: &promotion.BuyXOrMore{},
+		lanaPenCode:    {Code: lanaPenCode, Name: "Lana Pen", Price: basket.NewMoney(500, basket.DefaultCurrency), Category: "office"},
+		lanaTshirtCode: {Code: lanaTshirtCode, Name: "Lana T-Shirt", Price: basket.NewMoney(2000, basket.DefaultCurrency), Category: "clothing"},
+		lanaMugCode:    {Code: lanaMugCode, Name: "Lana Coffee Mug ", Price: basket.NewMoney(750, basket.DefaultCurrency), Category: "drinkware"},
 	}
 )
 
@@ -35,31 +38,203 @@ var (
 	ErrBktNotFound = errors.New("basket not found")
 	// ErrInvalidProductCode is used when the product code does not belong to one supported
 	ErrInvalidProductCode = errors.New("invalid product code")
+	// ErrCouponAlreadyApplied is used when the basket already has a coupon.
+	ErrCouponAlreadyApplied = errors.New("basket already has a coupon")
+	// ErrCouponNotApplied is used when removing the coupon of a basket without one.
+	ErrCouponNotApplied = errors.New("basket has no coupon")
+	// ErrVariantRequired is used when adding a product that has variants, baskets keep the variant chosen.
+	ErrVariantRequired = errors.New("product has variants, one of them must be chosen")
+	// ErrOutOfStock is used when the stock left of a product is lower than the quantity added to a basket.
+	ErrOutOfStock = errors.New("product out of stock")
+	// ErrProductNotInBasket is used when removing a product the basket does not have.
+	ErrProductNotInBasket = errors.New("product not in basket")
+	// ErrInvalidQuantity is used when a product quantity is not greater than zero, greater than the units to remove
+	// or greater than maxQuantity.
+	ErrInvalidQuantity = errors.New("quantity must be greater than zero")
+	// ErrVersionMismatch is used when a basket is changed with the version of an older state of it.
+	ErrVersionMismatch = errors.New("basket version does not match")
+
+	errTooManyUnits = fmt.Errorf("%w: up to %d units of a product", ErrInvalidQuantity, maxQuantity)
 )
 
 // Service is responsible for service methods.
 type Service struct {
 	bktMutex   sync.Mutex
 	bktStorage map[string]basket.Basket
-	prdStorage map[string]basket.Product
-	promotions map[string]Promotion
+	// reserved is the number of units of each product in the active baskets, guarded by bktMutex.
+	reserved map[string]int
+	// bktTTL is how long a basket lives since its last update, baskets do not expire when it is zero.
+	bktTTL  time.Duration
+	catalog catalog.Store
+	// prmMutex guards the promotions, they are swapped as a whole so pricing always sees a consistent set.
+	prmMutex     sync.RWMutex
+	promotionCfg promotion.Config
+	promotions   promotion.Set
+	coupons      *coupon.Registry
+	clock        Clock
+	// catalogFile and promotionsFile are read again by Reload, when empty that configuration is not reloaded.
+	catalogFile    string
+	promotionsFile string
+}
+
+// Clock returns the current time, the time dependent rules like promotion schedules and coupon expirations use it.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Option configures the Service built by New.
+type Option func(*options)
+
+type options struct {
+	promotionsFile string
+	couponsFile    string
+	catalogFile    string
+	clock          Clock
+	catalog        catalog.Store
+	bktTTL         time.Duration
+}
+
+// WithBasketTTL makes the baskets expire when they are not updated for the ttl, releasing their stock.
+func WithBasketTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.bktTTL = ttl
+	}
+}
+
+// WithCatalog sets the catalog store of the Service, the default products kept in memory are used otherwise.
+func WithCatalog(store catalog.Store) Option {
+	return func(o *options) {
+		o.catalog = store
+	}
+}
+
+// WithCatalogFile loads the products of the catalog from the given CSV or JSON file instead of the default ones.
+// It is ignored when the catalog store is set with WithCatalog.
+func WithCatalogFile(path string) Option {
+	return func(o *options) {
+		o.catalogFile = path
+	}
+}
+
+// WithClock sets the clock of the Service, the system clock is used by default.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithPromotionsFile loads the promotions from the given configuration file instead of the default ones.
+func WithPromotionsFile(path string) Option {
+	return func(o *options) {
+		o.promotionsFile = path
+	}
+}
+
+// WithCouponsFile loads the coupons from the given configuration file instead of the default ones.
+func WithCouponsFile(path string) Option {
+	return func(o *options) {
+		o.couponsFile = path
+	}
 }
 
 // New returns a Service implementation.
-func New() *Service {
+// It fails if the catalog, promotions or coupons configuration can not be loaded, or promotions target products not in the catalog.
+func New(opts ...Option) (*Service, error) {
+	o := options{clock: systemClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.catalog == nil {
+		store, err := loadCatalog(o.catalogFile)
+		if err != nil {
+			return nil, err
+		}
+		o.catalog = store
+	} else {
+		o.catalogFile = ""
+	}
+	promotionCfg, err := loadPromotionConfig(o.promotionsFile)
+	if err != nil {
+		return nil, err
+	}
+	promotions, err := buildPromotionConfig(promotionCfg, o.catalog.Prices())
+	if err != nil {
+		return nil, err
+	}
+	coupons, err := buildCoupons(o.couponsFile)
+	if err != nil {
+		return nil, err
+	}
 	return &Service{
-		bktStorage: make(map[string]basket.Basket),
-		prdStorage: uploadProducts(),
-		promotions: buildPromotion(),
+		bktStorage:     make(map[string]basket.Basket),
+		reserved:       make(map[string]int),
+		bktTTL:         o.bktTTL,
+		catalog:        o.catalog,
+		promotionCfg:   promotionCfg,
+		promotions:     promotions,
+		coupons:        coupons,
+		clock:          o.clock,
+		catalogFile:    o.catalogFile,
+		promotionsFile: o.promotionsFile,
+	}, nil
+}
+
+func loadCatalog(path string) (catalog.Store, error) {
+	products := defaultProducts()
+	if path != "" {
+		var err error
+		if products, err = catalog.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return catalog.NewMemory(products...)
+}
+
+func loadPromotionConfig(path string) (promotion.Config, error) {
+	if path == "" {
+		return promotion.DefaultConfig(), nil
 	}
+	return promotion.LoadConfig(path)
 }
 
-func buildPromotion() map[string]Promotion {
-	return promotionMap
+// buildPromotionConfig builds the promotions once checked they only target products of the catalog.
+// Line promotions target the parent of the variants, they count the units of all of them.
+func buildPromotionConfig(cfg promotion.Config, products map[string]basket.Product) (promotion.Set, error) {
+	for _, def := range cfg.Promotions {
+		for _, code := range def.Products {
+			product, exist := products[code]
+			if !exist {
+				return promotion.Set{}, fmt.Errorf("%w: %q: unknown product %s", promotion.ErrInvalidPromotion, def.Name, code)
+			}
+			if product.Parent != "" && def.Type != promotion.TypeBundle {
+				return promotion.Set{}, fmt.Errorf("%w: %q: %s is a variant, target its parent %s",
+					promotion.ErrInvalidPromotion, def.Name, code, product.Parent)
+			}
+		}
+	}
+	return cfg.Build()
+}
+
+func buildCoupons(path string) (*coupon.Registry, error) {
+	cfg := coupon.DefaultConfig()
+	if path != "" {
+		var err error
+		if cfg, err = coupon.LoadConfig(path); err != nil {
+			return nil, err
+		}
+	}
+	return coupon.NewRegistry(cfg)
 }
 
-func uploadProducts() map[string]basket.Product {
-	return productMap
+func defaultProducts() []basket.Product {
+	return []basket.Product{productMap[lanaPenCode], productMap[lanaTshirtCode], productMap[lanaMugCode]}
 }
 
 // Create creates a basket with empty values.
@@ -67,102 +242,532 @@ func (s *Service) Create() basket.Basket {
 	s.bktMutex.Lock()
 	defer s.bktMutex.Unlock()
 
-	bkt := buildBkt()
+	now := s.clock.Now()
+	bkt := buildBkt(now)
+	bkt.Version = 1
+	bkt.ExpiresAt = s.expiresAt(now)
 
 	s.bktStorage[bkt.ID] = bkt
 	return bkt
 }
 
-func buildBkt() basket.Basket {
+func buildBkt(now time.Time) basket.Basket {
 	return basket.Basket{
-		ID:          xid.New().String(),
-		DateCreated: time.Now().UTC().Format("01-02-2006 15:04:05"),
-		Products:    make(map[string]int),
-		Status:      statusActive,
+		ID:            xid.New().String(),
+		DateCreated:   now.UTC().Format(dateLayout),
+		PriceLockedAt: now.UTC().Format(dateLayout),
+		Products:      make(map[string]int),
+		Items:         []basket.LineItem{},
+		Subtotal:      basket.NewMoney(0, basket.DefaultCurrency),
+		Discounts:     []basket.Discount{},
+		Amount:        basket.NewMoney(0, basket.DefaultCurrency),
+		Currency:      basket.DefaultCurrency,
+		Status:        statusOpen,
 	}
 }
 
+// Get returns the basket repriced with the current prices and promotions.
 func (s *Service) Get(bktID string) (basket.Basket, error) {
 	s.bktMutex.Lock()
 	defer s.bktMutex.Unlock()
-	bkt, exist := s.bktStorage[bktID]
-	if !exist || bkt.Status == statusInactive {
-		return basket.Basket{}, ErrBktNotFound
+	return s.getRepriced(bktID)
+}
+
+// GetAmount returns the amount of the basket repriced with the current prices and promotions, and an error if any.
+func (s *Service) GetAmount(bktID string) (basket.GetAmount, error) {
+	s.bktMutex.Lock()
+	defer s.bktMutex.Unlock()
+	bkt, err := s.getRepriced(bktID)
+	if err != nil {
+		return basket.GetAmount{}, err
 	}
-	return bkt, nil
+	return basket.GetAmount{
+		BktID:         bkt.ID,
+		Subtotal:      bkt.Subtotal,
+		Discounts:     bkt.Discounts,
+		Amount:        bkt.Amount,
+		Currency:      bkt.Currency,
+		PriceLockedAt: bkt.PriceLockedAt,
+		Version:       bkt.Version,
+	}, nil
+}
+
+// getRepriced returns the basket once repriced, amounts are computed lazily on read
+// so a change of prices, promotions or the time windows is seen without repricing every basket.
+// Baskets checking out or paid keep their price. The caller holds bktMutex.
+func (s *Service) getRepriced(bktID string) (basket.Basket, error) {
+	now := s.clock.Now()
+	bkt, err := s.lookup(bktID, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	return s.reprice(bkt, now), nil
 }
 
-// Promotion interface is used to manage the Promotion methods.
-type Promotion interface {
-	Compute(basket basket.Product, quantity int) float64
+// reprice stores the open basket with its current price. A new price is a new version of the basket,
+// so its ETag changes with it whether the basket is read or changed first. The caller holds bktMutex.
+func (s *Service) reprice(bkt basket.Basket, now time.Time) basket.Basket {
+	if bkt.Status != statusOpen {
+		return bkt
+	}
+	priced := s.calculateAmount(bkt, now)
+	if !samePrice(bkt, priced) {
+		priced.Version++
+	}
+	s.bktStorage[bkt.ID] = priced
+	return priced
 }
 
-// GetAmount returns the amount of the basket and an error if any.
-func (s *Service) GetAmount(bktID string) (float64, error) {
+// calculateAmount prices the basket with the configured promotions.
+// PriceLockedAt moves to now only when the price changed, so it tells since when the basket has its price.
+func (s *Service) calculateAmount(bkt basket.Basket, now time.Time) basket.Basket {
+	set, prices := s.currentPricing()
+	priced := s.price(set, prices, bkt, now)
+	if !samePrice(bkt, priced) {
+		priced.PriceLockedAt = now.UTC().Format(dateLayout)
+	}
+	return priced
+}
+
+func samePrice(a, b basket.Basket) bool {
+	return reflect.DeepEqual(a.Items, b.Items) && reflect.DeepEqual(a.Promotions, b.Promotions) &&
+		a.Subtotal == b.Subtotal && reflect.DeepEqual(a.Discounts, b.Discounts) && a.Amount == b.Amount
+}
+
+// price prices the basket with the cheapest combination of the promotions active at now,
+// then applies the basket discounts over the subtotal and last its coupon.
+func (s *Service) price(set promotion.Set, prices map[string]basket.Product, bkt basket.Basket, now time.Time) basket.Basket {
+	promotions := set.Active(now)
+	result := promotions.Solve(bkt.Products, prices, bkt.Currency)
+	bkt.Items = result.Lines
+	bkt.Promotions = result.Applied
+	bkt.Subtotal = result.Total
+	bkt.Discounts, bkt.Amount = promotions.ApplyDiscounts(result.Total)
+	if bkt.Items == nil {
+		bkt.Items = []basket.LineItem{}
+	}
+
+	if bkt.Coupon != "" {
+		if c, exists := s.coupons.Get(bkt.Coupon); exists {
+			discount := c.Discount(bkt.Amount, now)
+			bkt.Discounts = append(bkt.Discounts, basket.Discount{Name: c.Code, Type: discountCoupon, Amount: discount})
+			bkt.Amount = bkt.Amount.Sub(discount)
+		}
+	}
+	return bkt
+}
+
+// Delete deletes the basket sent by parameter, paid baskets can not be deleted.
+func (s *Service) Delete(bktID string, version int) error {
 	s.bktMutex.Lock()
 	defer s.bktMutex.Unlock()
+	bkt, err := s.lookupVersion(bktID, version, s.clock.Now())
+	if err != nil {
+		return err
+	}
+	if err := checkTransition(bkt.Status, statusDeleted); err != nil {
+		return err
+	}
+
+	s.deactivate(bkt, statusDeleted)
+	return nil
+}
+
+// lookup returns the basket unless it is abandoned or deleted, abandoning it when its time to live is over.
+// The caller holds bktMutex.
+func (s *Service) lookup(bktID string, now time.Time) (basket.Basket, error) {
 	bkt, exist := s.bktStorage[bktID]
-	if !exist || bkt.Status == statusInactive {
-		return 0, ErrBktNotFound
+	if !exist || !found(bkt.Status) {
+		return basket.Basket{}, ErrBktNotFound
 	}
-	return bkt.Amount, nil
+	if expired(bkt, now) {
+		s.deactivate(bkt, statusAbandoned)
+		return basket.Basket{}, ErrBktNotFound
+	}
+	return bkt, nil
 }
 
-func (s *Service) calculateAmount(bktID string) (float64, error) {
-	withoutPromo := promotion.WithoutPromo{}
+// lookupVersion returns the active basket like lookup, repriced, and ErrVersionMismatch when the version is not zero
+// and the basket has another one. The caller holds bktMutex.
+func (s *Service) lookupVersion(bktID string, version int, now time.Time) (basket.Basket, error) {
+	bkt, err := s.lookup(bktID, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	bkt = s.reprice(bkt, now)
+	if version != 0 && bkt.Version != version {
+		return basket.Basket{}, ErrVersionMismatch
+	}
+	return bkt, nil
+}
 
-	bkt, exist := s.bktStorage[bktID]
-	if !exist {
-		return 0, ErrBktNotFound
+// lookupOpen returns the basket like lookupVersion, and ErrBasketNotOpen when its contents can not change.
+// The caller holds bktMutex.
+func (s *Service) lookupOpen(bktID string, version int, now time.Time) (basket.Basket, error) {
+	bkt, err := s.lookupVersion(bktID, version, now)
+	if err != nil {
+		return basket.Basket{}, err
 	}
-	var amount float64
-	for productCode, quantity := range bkt.Products {
-		promo, exists := s.promotions[productCode]
-		if !exists {
-			amount += withoutPromo.Compute(s.prdStorage[productCode], quantity)
-		} else {
-			amount += promo.Compute(s.prdStorage[productCode], quantity)
-		}
+	if bkt.Status != statusOpen {
+		return basket.Basket{}, ErrBasketNotOpen
+	}
+	return bkt, nil
+}
+
+// deactivate releases the stock of the basket and moves it to the final status, abandoned or deleted.
+// The caller holds bktMutex.
+func (s *Service) deactivate(bkt basket.Basket, status string) {
+	for code, quantity := range bkt.Products {
+		s.release(code, quantity)
 	}
-	return amount, nil
+	bkt.Status = status
+	s.bktStorage[bkt.ID] = bkt
 }
 
-// Delete deletes the basket sent by parameter.
-func (s *Service) Delete(bktID string) error {
+// ExpireBaskets abandons the baskets whose time to live is over and returns how many expired.
+// Expired baskets are also found when read, this releases the stock of the ones nobody reads anymore.
+func (s *Service) ExpireBaskets() int {
 	s.bktMutex.Lock()
 	defer s.bktMutex.Unlock()
-	bkt, exist := s.bktStorage[bktID]
-	if !exist || bkt.Status == statusInactive {
-		return ErrBktNotFound
+	now := s.clock.Now()
+	count := 0
+	for _, bkt := range s.bktStorage {
+		if found(bkt.Status) && expired(bkt, now) {
+			s.deactivate(bkt, statusAbandoned)
+			count++
+		}
+	}
+	return count
+}
+
+// expiresAt returns when a basket updated at now expires, empty when baskets do not expire.
+func (s *Service) expiresAt(now time.Time) string {
+	if s.bktTTL <= 0 {
+		return ""
 	}
+	return now.Add(s.bktTTL).UTC().Format(dateLayout)
+}
 
-	// TODO add validation of status transitions
-	bkt.Status = statusInactive
-	s.bktStorage[bktID] = bkt
+func expired(bkt basket.Basket, now time.Time) bool {
+	if bkt.ExpiresAt == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(dateLayout, bkt.ExpiresAt)
+	return err == nil && !now.Before(expiresAt)
+}
+
+// reserve reserves the quantity of the product for a basket. The caller holds bktMutex.
+func (s *Service) reserve(product basket.Product, quantity int) error {
+	if product.Stock != nil {
+		available := *product.Stock - s.reserved[product.Code]
+		if quantity > available {
+			if available < 0 {
+				available = 0
+			}
+			return fmt.Errorf("%w: %q: %d units available", ErrOutOfStock, product.Code, available)
+		}
+	}
+	s.reserved[product.Code] += quantity
 	return nil
 }
 
+// release gives back the quantity of the product reserved by a basket. The caller holds bktMutex.
+func (s *Service) release(code string, quantity int) {
+	s.reserved[code] -= quantity
+	if s.reserved[code] <= 0 {
+		delete(s.reserved, code)
+	}
+}
+
 // AddProduct add a product to the basket.
-func (s *Service) AddProduct(bktID string, prdID string, quantity int) (basket.Basket, error) {
+func (s *Service) AddProduct(bktID string, prdID string, quantity int, version int) (basket.Basket, error) {
 	s.bktMutex.Lock()
 	defer s.bktMutex.Unlock()
-	bkt, exist := s.bktStorage[bktID]
-	if !exist || bkt.Status == statusInactive {
-		return basket.Basket{}, ErrBktNotFound
+	now := s.clock.Now()
+	bkt, err := s.lookupOpen(bktID, version, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	if quantity <= 0 {
+		return basket.Basket{}, ErrInvalidQuantity
+	}
+	if quantity > maxQuantity-bkt.Products[prdID] {
+		return basket.Basket{}, errTooManyUnits
 	}
 
-	_, exist = s.prdStorage[prdID]
-	if !exist {
+	product, err := s.catalog.Get(prdID)
+	if err != nil {
 		return basket.Basket{}, ErrInvalidProductCode
 	}
+	if len(s.catalog.Variants(prdID)) > 0 {
+		return basket.Basket{}, ErrVariantRequired
+	}
+	if err := s.reserve(product, quantity); err != nil {
+		return basket.Basket{}, err
+	}
 
+	bkt.Products = copyProducts(bkt.Products)
 	bkt.Products[prdID] += quantity
-	bkt.DateLastUpdated = time.Now().UTC().Format("01-02-2006 15:04:05")
-	amount, err := s.calculateAmount(bktID)
+	bkt.Version++
+	bkt.DateLastUpdated = now.UTC().Format(dateLayout)
+	bkt.ExpiresAt = s.expiresAt(now)
+	bkt = s.calculateAmount(bkt, now)
+	s.bktStorage[bktID] = bkt
+	return bkt, nil
+}
+
+// RemoveProduct takes quantity units of the product out of the basket and releases their stock,
+// all of them when quantity is zero. The product is removed from the basket when no units are left.
+func (s *Service) RemoveProduct(bktID string, prdID string, quantity int, version int) (basket.Basket, error) {
+	s.bktMutex.Lock()
+	defer s.bktMutex.Unlock()
+	now := s.clock.Now()
+	bkt, err := s.lookupOpen(bktID, version, now)
 	if err != nil {
 		return basket.Basket{}, err
 	}
-	bkt.Amount = amount
+	units, exist := bkt.Products[prdID]
+	if !exist {
+		return basket.Basket{}, ErrProductNotInBasket
+	}
+	if quantity < 0 || quantity > units {
+		return basket.Basket{}, fmt.Errorf("%w: the basket has %d units of %q", ErrInvalidQuantity, units, prdID)
+	}
+	if quantity == 0 {
+		quantity = units
+	}
+
+	bkt.Products = copyProducts(bkt.Products)
+	if bkt.Products[prdID] -= quantity; bkt.Products[prdID] == 0 {
+		delete(bkt.Products, prdID)
+	}
+	s.release(prdID, quantity)
+	bkt.Version++
+	bkt.DateLastUpdated = now.UTC().Format(dateLayout)
+	bkt.ExpiresAt = s.expiresAt(now)
+	bkt = s.calculateAmount(bkt, now)
 	s.bktStorage[bktID] = bkt
 	return bkt, nil
 }
+
+// SetProductQuantity sets the units of the product in the basket, so retrying it leaves the same basket.
+// Zero removes the product.
+func (s *Service) SetProductQuantity(bktID string, prdID string, quantity int, version int) (basket.Basket, error) {
+	s.bktMutex.Lock()
+	defer s.bktMutex.Unlock()
+	now := s.clock.Now()
+	bkt, err := s.lookupOpen(bktID, 0, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	return s.updateProducts(bkt, map[string]int{prdID: quantity}, false, version, now)
+}
+
+// ReplaceProducts replaces the contents of the basket with the products and their units.
+func (s *Service) ReplaceProducts(bktID string, products map[string]int, version int) (basket.Basket, error) {
+	s.bktMutex.Lock()
+	defer s.bktMutex.Unlock()
+	now := s.clock.Now()
+	bkt, err := s.lookupOpen(bktID, 0, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	return s.updateProducts(bkt, products, true, version, now)
+}
+
+// updateProducts sets the units of the products, the other products of the basket are removed when replace is set.
+// Every product is checked and its stock reserved before changing the basket, so it changes entirely or not at all.
+// Units can be lowered for products no longer in the catalog. When the basket already has the products it is
+// returned as is, whatever the version, so retrying a change does not fail nor make a new version.
+// The caller holds bktMutex.
+func (s *Service) updateProducts(bkt basket.Basket, units map[string]int, replace bool, version int, now time.Time) (basket.Basket, error) {
+	products := make(map[string]int, len(bkt.Products)+len(units))
+	if !replace {
+		for code, quantity := range bkt.Products {
+			products[code] = quantity
+		}
+	}
+	for code, quantity := range units {
+		if quantity < 0 {
+			return basket.Basket{}, ErrInvalidQuantity
+		}
+		if quantity > maxQuantity {
+			return basket.Basket{}, errTooManyUnits
+		}
+		if quantity == 0 {
+			delete(products, code)
+			continue
+		}
+		products[code] = quantity
+	}
+	if sameProducts(products, bkt.Products) {
+		return bkt, nil
+	}
+	if version != 0 && bkt.Version != version {
+		return basket.Basket{}, ErrVersionMismatch
+	}
+
+	reserved := make(map[string]int)
+	rollback := func() {
+		for code, quantity := range reserved {
+			s.release(code, quantity)
+		}
+	}
+	for _, code := range sortedCodes(products) {
+		added := products[code] - bkt.Products[code]
+		if added <= 0 {
+			continue
+		}
+		product, err := s.catalog.Get(code)
+		if err != nil {
+			rollback()
+			return basket.Basket{}, ErrInvalidProductCode
+		}
+		if len(s.catalog.Variants(code)) > 0 {
+			rollback()
+			return basket.Basket{}, ErrVariantRequired
+		}
+		if err := s.reserve(product, added); err != nil {
+			rollback()
+			return basket.Basket{}, err
+		}
+		reserved[code] = added
+	}
+	for code, quantity := range bkt.Products {
+		if removed := quantity - products[code]; removed > 0 {
+			s.release(code, removed)
+		}
+	}
+
+	bkt.Products = products
+	bkt.Version++
+	bkt.DateLastUpdated = now.UTC().Format(dateLayout)
+	bkt.ExpiresAt = s.expiresAt(now)
+	bkt = s.calculateAmount(bkt, now)
+	s.bktStorage[bkt.ID] = bkt
+	return bkt, nil
+}
+
+// copyProducts returns a copy of the products of a basket to change it, the stored map is shared
+// with the baskets already returned, which are read without holding bktMutex.
+func copyProducts(products map[string]int) map[string]int {
+	copied := make(map[string]int, len(products)+1)
+	for code, quantity := range products {
+		copied[code] = quantity
+	}
+	return copied
+}
+
+func sameProducts(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for code, quantity := range a {
+		if b[code] != quantity {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedCodes(products map[string]int) []string {
+	codes := make([]string, 0, len(products))
+	for code := range products {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// ApplyCoupon applies the coupon to the basket, its redemption is counted when the basket is paid.
+// A basket has at most one coupon, it must be removed before applying another one.
+func (s *Service) ApplyCoupon(bktID string, code string, version int) (basket.Basket, error) {
+	s.bktMutex.Lock()
+	defer s.bktMutex.Unlock()
+	now := s.clock.Now()
+	bkt, err := s.lookupOpen(bktID, version, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	if bkt.Coupon != "" {
+		return basket.Basket{}, ErrCouponAlreadyApplied
+	}
+
+	// The coupon is checked against the amount it discounts, after the promotions and the basket discounts.
+	bkt = s.calculateAmount(bkt, now)
+	c, err := s.coupons.Check(code, bkt.Amount, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	bkt.Coupon = c.Code
+	bkt.Version++
+	bkt.DateLastUpdated = now.UTC().Format(dateLayout)
+	bkt.ExpiresAt = s.expiresAt(now)
+	bkt = s.calculateAmount(bkt, now)
+	s.bktStorage[bktID] = bkt
+	return bkt, nil
+}
+
+// RemoveCoupon removes the coupon of the basket.
+func (s *Service) RemoveCoupon(bktID string, version int) (basket.Basket, error) {
+	s.bktMutex.Lock()
+	defer s.bktMutex.Unlock()
+	now := s.clock.Now()
+	bkt, err := s.lookupOpen(bktID, version, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	if bkt.Coupon == "" {
+		return basket.Basket{}, ErrCouponNotApplied
+	}
+
+	bkt.Coupon = ""
+	bkt.Version++
+	bkt.DateLastUpdated = now.UTC().Format(dateLayout)
+	bkt.ExpiresAt = s.expiresAt(now)
+	bkt = s.calculateAmount(bkt, now)
+	s.bktStorage[bktID] = bkt
+	return bkt, nil
+}
+
+// Quote prices the items as a basket would, without storing any basket.
+// When promotions is not nil it replaces the configured promotions, and at sets the pricing time instead of now.
+func (s *Service) Quote(items []basket.QuoteItem, promotions []promotion.Definition, at time.Time) (basket.Quote, error) {
+	bkt := basket.Basket{Products: make(map[string]int), Currency: basket.DefaultCurrency}
+	for _, item := range items {
+		if _, err := s.catalog.Get(item.Code); err != nil {
+			return basket.Quote{}, ErrInvalidProductCode
+		}
+		if len(s.catalog.Variants(item.Code)) > 0 {
+			return basket.Quote{}, ErrVariantRequired
+		}
+		if item.Quantity <= 0 {
+			return basket.Quote{}, ErrInvalidQuantity
+		}
+		if item.Quantity > maxQuantity-bkt.Products[item.Code] {
+			return basket.Quote{}, errTooManyUnits
+		}
+		bkt.Products[item.Code] += item.Quantity
+	}
+
+	set, prices := s.currentPricing()
+	if promotions != nil {
+		var err error
+		if set, err = buildPromotionConfig(promotion.Config{Promotions: promotions}, prices); err != nil {
+			return basket.Quote{}, err
+		}
+	}
+	if at.IsZero() {
+		at = s.clock.Now()
+	}
+
+	bkt = s.price(set, prices, bkt, at)
+	return basket.Quote{
+		Items:      bkt.Items,
+		Subtotal:   bkt.Subtotal,
+		Discounts:  bkt.Discounts,
+		Amount:     bkt.Amount,
+		Promotions: bkt.Promotions,
+		Currency:   bkt.Currency,
+	}, nil
+}
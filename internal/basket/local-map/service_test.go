@@ -1,31 +1,140 @@
 package local_map
 
 import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+	"github.com/mercadolibre/backend-challenge/internal/basket/coupon"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
 	"github.com/stretchr/testify/require"
 )
 
 func TestNewService(t *testing.T) {
-	service := New()
+	service, err := New()
+	require.NoError(t, err)
 	require.Equal(t, service.bktStorage, make(map[string]basket.Basket))
-	require.Equal(t, service.prdStorage, productMap)
-	require.Equal(t, service.promotions, promotionMap)
+	require.Equal(t, service.catalog.Prices(), productMap)
+	defaultPromotions, err := promotion.DefaultConfig().Build()
+	require.NoError(t, err)
+	require.Equal(t, service.promotions, defaultPromotions)
+}
+
+func TestNewServiceWithCatalogFile(t *testing.T) {
+	service, err := New(WithCatalogFile("../../../config/catalog.csv"))
+	require.NoError(t, err)
+	require.Equal(t, productMap, service.catalog.Prices())
+
+	path := filepath.Join(t.TempDir(), "catalog.csv")
+	err = ioutil.WriteFile(path, []byte("code,name,price,category\nCAP,Lana Cap,12.5,clothing\n"), 0600)
+	require.NoError(t, err)
+	// The default promotions target products not in the catalog.
+	_, err = New(WithCatalogFile(path))
+	require.True(t, errors.Is(err, promotion.ErrInvalidPromotion))
+
+	err = ioutil.WriteFile(path, []byte("code,name,price\nPEN,Lana Pen,5\nMUG,,7.5\n"), 0600)
+	require.NoError(t, err)
+	_, err = New(WithCatalogFile(path))
+	require.True(t, errors.Is(err, catalog.ErrInvalidCatalog))
+}
+
+func TestNewServiceWithPromotionsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "promotions.json")
+	err := ioutil.WriteFile(path, []byte(`{"promotions": [
+		{"name": "mugs-bulk", "type": "buy_x_or_more", "products": ["MUG"], "min_quantity": 5, "discount_percent": 10}
+	]}`), 0600)
+	require.NoError(t, err)
+
+	service, err := New(WithPromotionsFile(path))
+	require.NoError(t, err)
+	bkt := service.Create()
+	bkt, err = service.AddProduct(bkt.ID, lanaMugCode, 5, 0)
+	require.NoError(t, err)
+	require.Equal(t, basket.NewMoney(3375, basket.DefaultCurrency), bkt.Amount)
+	bkt, err = service.AddProduct(bkt.ID, lanaPenCode, 2, 0)
+	require.NoError(t, err)
+	require.Equal(t, basket.NewMoney(4375, basket.DefaultCurrency), bkt.Amount)
+
+	err = ioutil.WriteFile(path, []byte(`{"promotions": [
+		{"name": "unknown", "type": "buy_2_get_1_free", "products": ["RANDOM"]}
+	]}`), 0600)
+	require.NoError(t, err)
+	_, err = New(WithPromotionsFile(path))
+	require.True(t, errors.Is(err, promotion.ErrInvalidPromotion))
+}
+
+func TestAddProductWithBundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "promotions.json")
+	err := ioutil.WriteFile(path, []byte(`{"promotions": [
+		{"name": "lana-pack", "type": "bundle", "products": ["PEN", "MUG", "TSHIRT"], "price": 25},
+		{"name": "pen-2x1", "type": "buy_n_pay_m", "products": ["PEN"], "buy": 2, "pay": 1},
+		{"name": "tshirt-bulk", "type": "buy_x_or_more", "products": ["TSHIRT"], "min_quantity": 3, "discount_percent": 25}
+	]}`), 0600)
+	require.NoError(t, err)
+	service, err := New(WithPromotionsFile(path))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		products       map[string]int
+		expectedAmount int64
+	}{
+		{
+			name:           "Bundle - Not complete",
+			products:       map[string]int{lanaPenCode: 2, lanaMugCode: 1},
+			expectedAmount: 1250,
+		},
+		{
+			name:           "Bundle - Complete",
+			products:       map[string]int{lanaPenCode: 1, lanaTshirtCode: 1, lanaMugCode: 1},
+			expectedAmount: 2500,
+		},
+		{
+			// With the bundle the two pens left go 2x1 and the two t-shirts left lose the bulk discount (70€).
+			name:           "Bundle - Skipped when line promotions are cheaper",
+			products:       map[string]int{lanaPenCode: 3, lanaTshirtCode: 3, lanaMugCode: 1},
+			expectedAmount: 6250,
+		},
+		{
+			// One bundle (25€) and the three t-shirts left with the bulk discount (45€).
+			name:           "Bundle - Units left use line promotions",
+			products:       map[string]int{lanaPenCode: 1, lanaTshirtCode: 4, lanaMugCode: 1},
+			expectedAmount: 7000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bkt := service.Create()
+			for productCode, quantity := range tt.products {
+				bkt, err = service.AddProduct(bkt.ID, productCode, quantity, 0)
+				require.NoError(t, err)
+			}
+			require.Equal(t, basket.NewMoney(tt.expectedAmount, basket.DefaultCurrency), bkt.Amount)
+		})
+	}
 }
 
 func TestCreate(t *testing.T) {
-	service := New()
+	service, err := New()
+	require.NoError(t, err)
 	bkt := service.Create()
 	require.Equal(t, bkt.Products, make(map[string]int))
-	require.Equal(t, bkt.Status, statusActive)
+	require.Equal(t, bkt.Status, statusOpen)
 }
 
 func TestGet(t *testing.T) {
-	service := New()
+	service, err := New()
+	require.NoError(t, err)
 	bktAdded := service.Create()
 	bktAddedInactive := service.Create()
-	err := service.Delete(bktAddedInactive.ID)
+	err = service.Delete(bktAddedInactive.ID, 0)
 	require.NoError(t, err)
 	tests := []struct {
 		name             string
@@ -62,32 +171,41 @@ func TestGet(t *testing.T) {
 }
 
 func TestGetAmount(t *testing.T) {
-	service := New()
+	service, err := New()
+	require.NoError(t, err)
 	bktAdded := service.Create()
 	bktAddedInactive := service.Create()
-	err := service.Delete(bktAddedInactive.ID)
+	err = service.Delete(bktAddedInactive.ID, 0)
 	require.NoError(t, err)
 	tests := []struct {
 		name             string
 		bktID            string
-		expectedResponse float64
+		expectedResponse basket.GetAmount
 		expectedErr      error
 	}{
 		{
-			name:             "Get amount - Ok",
-			bktID:            bktAdded.ID,
-			expectedResponse: 0.00,
+			name:  "Get amount - Ok",
+			bktID: bktAdded.ID,
+			expectedResponse: basket.GetAmount{
+				BktID:         bktAdded.ID,
+				Subtotal:      basket.NewMoney(0, basket.DefaultCurrency),
+				Discounts:     []basket.Discount{},
+				Amount:        basket.NewMoney(0, basket.DefaultCurrency),
+				Currency:      basket.DefaultCurrency,
+				PriceLockedAt: bktAdded.PriceLockedAt,
+				Version:       bktAdded.Version,
+			},
 		},
 		{
 			name:             "Get amount - Not Found",
 			bktID:            "randomID",
-			expectedResponse: 0.00,
+			expectedResponse: basket.GetAmount{},
 			expectedErr:      ErrBktNotFound,
 		},
 		{
 			name:             "Get amount - Not Found - Status inactive",
 			bktID:            bktAddedInactive.ID,
-			expectedResponse: 0.00,
+			expectedResponse: basket.GetAmount{},
 			expectedErr:      ErrBktNotFound,
 		},
 	}
@@ -102,10 +220,11 @@ func TestGetAmount(t *testing.T) {
 }
 
 func TestDelete(t *testing.T) {
-	service := New()
+	service, err := New()
+	require.NoError(t, err)
 	bktAdded := service.Create()
 	bktAddedInactive := service.Create()
-	err := service.Delete(bktAddedInactive.ID)
+	err = service.Delete(bktAddedInactive.ID, 0)
 	require.NoError(t, err)
 	tests := []struct {
 		name             string
@@ -130,26 +249,27 @@ func TestDelete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.Delete(tt.bktID)
+			err = service.Delete(tt.bktID, 0)
 			require.Equal(t, err, tt.expectedResponse)
 		})
 	}
 }
 
 func TestAddProduct(t *testing.T) {
-	service := New()
+	service, err := New()
+	require.NoError(t, err)
 	bktAdded1 := service.Create()
 	bktAdded2 := service.Create()
 	bktAdded3 := service.Create()
 	bktAdded4 := service.Create()
 	bktAddedDeleted := service.Create()
-	err := service.Delete(bktAddedDeleted.ID)
+	err = service.Delete(bktAddedDeleted.ID, 0)
 	require.NoError(t, err)
 	tests := []struct {
 		name           string
 		bktID          string
 		products       map[string]int
-		expectedAmount float64
+		expectedAmount basket.Money
 		expectedError  error
 	}{
 		{
@@ -160,7 +280,7 @@ func TestAddProduct(t *testing.T) {
 				lanaTshirtCode: 1,
 				lanaMugCode:    1,
 			},
-			expectedAmount: 32.50,
+			expectedAmount: basket.NewMoney(3250, basket.DefaultCurrency),
 		},
 		{
 			name:  "AddProduct - Ok - Case 2",
@@ -169,7 +289,7 @@ func TestAddProduct(t *testing.T) {
 				lanaPenCode:    2,
 				lanaTshirtCode: 1,
 			},
-			expectedAmount: 25,
+			expectedAmount: basket.NewMoney(2500, basket.DefaultCurrency),
 		},
 		{
 			name:  "AddProduct - Ok - Case 3",
@@ -178,7 +298,7 @@ func TestAddProduct(t *testing.T) {
 				lanaPenCode:    1,
 				lanaTshirtCode: 4,
 			},
-			expectedAmount: 65,
+			expectedAmount: basket.NewMoney(6500, basket.DefaultCurrency),
 		},
 		{
 			name:  "AddProduct - Ok - Case 4",
@@ -188,7 +308,7 @@ func TestAddProduct(t *testing.T) {
 				lanaTshirtCode: 3,
 				lanaMugCode:    1,
 			},
-			expectedAmount: 62.5,
+			expectedAmount: basket.NewMoney(6250, basket.DefaultCurrency),
 		},
 		{
 			name:  "Get amount - Not Found - Status inactive",
@@ -198,7 +318,7 @@ func TestAddProduct(t *testing.T) {
 				lanaTshirtCode: 3,
 				lanaMugCode:    1,
 			},
-			expectedAmount: 0.00,
+			expectedAmount: basket.Money{},
 			expectedError:  ErrBktNotFound,
 		},
 		{
@@ -207,16 +327,34 @@ func TestAddProduct(t *testing.T) {
 			products: map[string]int{
 				"randomProductID": 3,
 			},
-			expectedAmount: 0.00,
+			expectedAmount: basket.Money{},
 			expectedError:  ErrInvalidProductCode,
 		},
+		{
+			name:  "AddProduct - Invalid quantity",
+			bktID: bktAdded1.ID,
+			products: map[string]int{
+				lanaPenCode: -1,
+			},
+			expectedAmount: basket.Money{},
+			expectedError:  ErrInvalidQuantity,
+		},
+		{
+			name:  "AddProduct - Too many units",
+			bktID: bktAdded1.ID,
+			products: map[string]int{
+				lanaPenCode: maxQuantity + 1,
+			},
+			expectedAmount: basket.Money{},
+			expectedError:  errTooManyUnits,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var bkt basket.Basket
 			for productCode, quantity := range tt.products {
-				bkt, err = service.AddProduct(tt.bktID, productCode, quantity)
+				bkt, err = service.AddProduct(tt.bktID, productCode, quantity, 0)
 				if tt.expectedError != nil {
 					require.Equal(t, err, tt.expectedError)
 					require.Equal(t, bkt, basket.Basket{})
@@ -227,6 +365,693 @@ func TestAddProduct(t *testing.T) {
 				}
 			}
 			require.Equal(t, bkt.Amount, tt.expectedAmount)
+			require.Len(t, bkt.Items, len(tt.products))
+			net := basket.NewMoney(0, basket.DefaultCurrency)
+			for _, item := range bkt.Items {
+				require.Equal(t, tt.products[item.Code], item.Quantity)
+				net = net.Add(item.Net)
+			}
+			require.Equal(t, tt.expectedAmount, net)
+		})
+	}
+}
+
+func TestRemoveProduct(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	bkt := service.Create()
+	_, err = service.AddProduct(bkt.ID, lanaPenCode, 3, 0)
+	require.NoError(t, err)
+	_, err = service.AddProduct(bkt.ID, lanaMugCode, 1, 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		code             string
+		quantity         int
+		expectedProducts map[string]int
+		expectedAmount   int64
+		expectedErr      error
+	}{
+		{name: "RemoveProduct - Not in basket", code: lanaTshirtCode, expectedErr: ErrProductNotInBasket},
+		{name: "RemoveProduct - More units than in the basket", code: lanaPenCode, quantity: 4, expectedErr: ErrInvalidQuantity},
+		{name: "RemoveProduct - Negative quantity", code: lanaPenCode, quantity: -1, expectedErr: ErrInvalidQuantity},
+		{
+			name:             "RemoveProduct - Ok - Decrement",
+			code:             lanaPenCode,
+			quantity:         2,
+			expectedProducts: map[string]int{lanaPenCode: 1, lanaMugCode: 1},
+			expectedAmount:   1250,
+		},
+		{
+			name:             "RemoveProduct - Ok - All units",
+			code:             lanaMugCode,
+			expectedProducts: map[string]int{lanaPenCode: 1},
+			expectedAmount:   500,
+		},
+		{
+			name:             "RemoveProduct - Ok - Last unit",
+			code:             lanaPenCode,
+			quantity:         1,
+			expectedProducts: map[string]int{},
+			expectedAmount:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.RemoveProduct(bkt.ID, tt.code, tt.quantity, 0)
+			if tt.expectedErr != nil {
+				require.True(t, errors.Is(err, tt.expectedErr))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedProducts, got.Products)
+			require.Len(t, got.Items, len(tt.expectedProducts))
+			require.Equal(t, basket.NewMoney(tt.expectedAmount, basket.DefaultCurrency), got.Amount)
+		})
+	}
+
+	require.NoError(t, service.Delete(bkt.ID, 0))
+	_, err = service.RemoveProduct(bkt.ID, lanaPenCode, 1, 0)
+	require.Equal(t, ErrBktNotFound, err)
+}
+
+func TestChangeProductsWhileEncoding(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	bkt := service.Create()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			got, err := service.Get(bkt.ID)
+			require.NoError(t, err)
+			_, err = json.Marshal(got)
+			require.NoError(t, err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_, err := service.AddProduct(bkt.ID, lanaPenCode, 1, 0)
+			require.NoError(t, err)
+			_, err = service.RemoveProduct(bkt.ID, lanaPenCode, 1, 0)
+			require.NoError(t, err)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSetProductQuantity(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	bkt := service.Create()
+
+	tests := []struct {
+		name             string
+		code             string
+		quantity         int
+		expectedProducts map[string]int
+		expectedErr      error
+	}{
+		{name: "SetProductQuantity - Invalid product code", code: "RANDOM", quantity: 1, expectedErr: ErrInvalidProductCode},
+		{name: "SetProductQuantity - Negative quantity", code: lanaPenCode, quantity: -1, expectedErr: ErrInvalidQuantity},
+		{name: "SetProductQuantity - Too many units", code: lanaPenCode, quantity: maxQuantity + 1, expectedErr: ErrInvalidQuantity},
+		{name: "SetProductQuantity - Ok - Add", code: lanaPenCode, quantity: 3, expectedProducts: map[string]int{lanaPenCode: 3}},
+		{name: "SetProductQuantity - Ok - Retry", code: lanaPenCode, quantity: 3, expectedProducts: map[string]int{lanaPenCode: 3}},
+		{name: "SetProductQuantity - Ok - Decrement", code: lanaPenCode, quantity: 1, expectedProducts: map[string]int{lanaPenCode: 1}},
+		{name: "SetProductQuantity - Ok - Zero not in basket", code: lanaMugCode, expectedProducts: map[string]int{lanaPenCode: 1}},
+		{name: "SetProductQuantity - Ok - Zero removes", code: lanaPenCode, expectedProducts: map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.SetProductQuantity(bkt.ID, tt.code, tt.quantity, 0)
+			if tt.expectedErr != nil {
+				require.True(t, errors.Is(err, tt.expectedErr))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedProducts, got.Products)
+		})
+	}
+
+	_, err = service.SetProductQuantity("RANDOM", lanaPenCode, 1, 0)
+	require.Equal(t, ErrBktNotFound, err)
+}
+
+func TestRetryProductChanges(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2021, 11, 5, 10, 0, 0, 0, time.UTC)}
+	service, err := New(WithClock(clock))
+	require.NoError(t, err)
+	bkt := service.Create()
+
+	first, err := service.SetProductQuantity(bkt.ID, lanaPenCode, 2, bkt.Version)
+	require.NoError(t, err)
+	require.Equal(t, 2, first.Version)
+
+	// Retrying with the same If-Match gets the basket as it is, without a new version.
+	clock.now = clock.now.Add(time.Minute)
+	retried, err := service.SetProductQuantity(bkt.ID, lanaPenCode, 2, bkt.Version)
+	require.NoError(t, err)
+	require.Equal(t, first, retried)
+	retried, err = service.ReplaceProducts(bkt.ID, map[string]int{lanaPenCode: 2}, 0)
+	require.NoError(t, err)
+	require.Equal(t, first, retried)
+
+	// A change with the stale version is still rejected.
+	_, err = service.SetProductQuantity(bkt.ID, lanaPenCode, 3, bkt.Version)
+	require.Equal(t, ErrVersionMismatch, err)
+}
+
+func TestReplaceProducts(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	bkt := service.Create()
+	_, err = service.AddProduct(bkt.ID, lanaPenCode, 3, 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		products         map[string]int
+		expectedProducts map[string]int
+		expectedAmount   int64
+		expectedErr      error
+	}{
+		{name: "ReplaceProducts - Invalid product code", products: map[string]int{lanaMugCode: 1, "RANDOM": 1}, expectedErr: ErrInvalidProductCode},
+		{name: "ReplaceProducts - Negative quantity", products: map[string]int{lanaMugCode: -1}, expectedErr: ErrInvalidQuantity},
+		{name: "ReplaceProducts - Too many units", products: map[string]int{lanaMugCode: maxQuantity + 1}, expectedErr: ErrInvalidQuantity},
+		{
+			name:             "ReplaceProducts - Ok",
+			products:         map[string]int{lanaMugCode: 2, lanaTshirtCode: 1, lanaPenCode: 0},
+			expectedProducts: map[string]int{lanaMugCode: 2, lanaTshirtCode: 1},
+			expectedAmount:   3500,
+		},
+		{
+			name:             "ReplaceProducts - Ok - Retry",
+			products:         map[string]int{lanaMugCode: 2, lanaTshirtCode: 1},
+			expectedProducts: map[string]int{lanaMugCode: 2, lanaTshirtCode: 1},
+			expectedAmount:   3500,
+		},
+		{name: "ReplaceProducts - Ok - Empty", products: map[string]int{}, expectedProducts: map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.ReplaceProducts(bkt.ID, tt.products, 0)
+			if tt.expectedErr != nil {
+				require.True(t, errors.Is(err, tt.expectedErr))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedProducts, got.Products)
+			require.Equal(t, basket.NewMoney(tt.expectedAmount, basket.DefaultCurrency), got.Amount)
 		})
 	}
+
+	_, err = service.ReplaceProducts("RANDOM", map[string]int{}, 0)
+	require.Equal(t, ErrBktNotFound, err)
+}
+
+func TestVersion(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	bkt := service.Create()
+	require.Equal(t, 1, bkt.Version)
+
+	tests := []struct {
+		name            string
+		run             func(version int) (basket.Basket, error)
+		version         int
+		expectedVersion int
+		expectedErr     error
+	}{
+		{
+			name:            "Version - Ok - Add product",
+			run:             func(version int) (basket.Basket, error) { return service.AddProduct(bkt.ID, lanaPenCode, 1, version) },
+			version:         1,
+			expectedVersion: 2,
+		},
+		{
+			name:        "Version - Mismatch - Stale version",
+			run:         func(version int) (basket.Basket, error) { return service.AddProduct(bkt.ID, lanaPenCode, 1, version) },
+			version:     1,
+			expectedErr: ErrVersionMismatch,
+		},
+		{
+			name: "Version - Ok - Without version",
+			run: func(version int) (basket.Basket, error) {
+				return service.SetProductQuantity(bkt.ID, lanaPenCode, 3, version)
+			},
+			expectedVersion: 3,
+		},
+		{
+			name:            "Version - Ok - Get does not change it",
+			run:             func(int) (basket.Basket, error) { return service.Get(bkt.ID) },
+			expectedVersion: 3,
+		},
+		{
+			name:        "Version - Mismatch - Apply coupon",
+			run:         func(version int) (basket.Basket, error) { return service.ApplyCoupon(bkt.ID, "LANA10", version) },
+			version:     2,
+			expectedErr: ErrVersionMismatch,
+		},
+		{
+			name: "Version - Ok - Remove product",
+			run: func(version int) (basket.Basket, error) {
+				return service.RemoveProduct(bkt.ID, lanaPenCode, 1, version)
+			},
+			version:         3,
+			expectedVersion: 4,
+		},
+		{
+			name:        "Version - Failed change keeps it",
+			run:         func(version int) (basket.Basket, error) { return service.RemoveCoupon(bkt.ID, version) },
+			version:     4,
+			expectedErr: ErrCouponNotApplied,
+		},
+		{
+			name: "Version - Not found first",
+			run: func(version int) (basket.Basket, error) {
+				return service.ReplaceProducts("RANDOM", map[string]int{}, version)
+			},
+			version:     4,
+			expectedErr: ErrBktNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.run(tt.version)
+			if tt.expectedErr != nil {
+				require.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedVersion, got.Version)
+		})
+	}
+
+	require.Equal(t, ErrVersionMismatch, service.Delete(bkt.ID, 3))
+	require.NoError(t, service.Delete(bkt.ID, 4))
+}
+
+func TestApplyCoupon(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "coupons.json")
+	err := ioutil.WriteFile(path, []byte(`{"coupons": [
+		{"code": "LANA10", "type": "percentage", "discount_percent": 10},
+		{"code": "FIVE", "type": "fixed", "amount": 5, "min_amount": 20},
+		{"code": "ONCE", "type": "fixed", "amount": 1, "max_redemptions": 1},
+		{"code": "OLD", "type": "percentage", "discount_percent": 50, "expires_at": "2000-01-01T00:00:00Z"}
+	]}`), 0600)
+	require.NoError(t, err)
+	service, err := New(WithCouponsFile(path))
+	require.NoError(t, err)
+
+	newBkt := func(products map[string]int) string {
+		bkt := service.Create()
+		for code, quantity := range products {
+			_, err := service.AddProduct(bkt.ID, code, quantity, 0)
+			require.NoError(t, err)
+		}
+		return bkt.ID
+	}
+	withCoupon := newBkt(map[string]int{lanaMugCode: 1})
+	_, err = service.ApplyCoupon(withCoupon, "LANA10", 0)
+	require.NoError(t, err)
+	// ONCE is used up by a paid basket.
+	paid := newBkt(map[string]int{lanaMugCode: 1})
+	_, err = service.ApplyCoupon(paid, "ONCE", 0)
+	require.NoError(t, err)
+	_, err = service.Checkout(paid, 0)
+	require.NoError(t, err)
+	_, err = service.Pay(paid, 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		bktID            string
+		code             string
+		expectedSubtotal int64
+		expectedAmount   int64
+		expectedErr      error
+	}{
+		{
+			name:             "Apply coupon - Ok - Percentage over the promotions price",
+			bktID:            newBkt(map[string]int{lanaPenCode: 3, lanaTshirtCode: 3, lanaMugCode: 1}),
+			code:             "lana10",
+			expectedSubtotal: 6250,
+			expectedAmount:   5625,
+		},
+		{
+			name:             "Apply coupon - Ok - Fixed",
+			bktID:            newBkt(map[string]int{lanaTshirtCode: 1}),
+			code:             "FIVE",
+			expectedSubtotal: 2000,
+			expectedAmount:   1500,
+		},
+		{
+			name:        "Apply coupon - Not found - Bkt",
+			bktID:       "randomID",
+			code:        "LANA10",
+			expectedErr: ErrBktNotFound,
+		},
+		{
+			name:        "Apply coupon - Not found - Coupon",
+			bktID:       newBkt(nil),
+			code:        "RANDOM",
+			expectedErr: coupon.ErrCouponNotFound,
+		},
+		{
+			name:        "Apply coupon - Already applied",
+			bktID:       withCoupon,
+			code:        "FIVE",
+			expectedErr: ErrCouponAlreadyApplied,
+		},
+		{
+			name:        "Apply coupon - Min amount not reached",
+			bktID:       newBkt(map[string]int{lanaPenCode: 1}),
+			code:        "FIVE",
+			expectedErr: coupon.ErrMinAmountNotReached,
+		},
+		{
+			name:        "Apply coupon - Exhausted",
+			bktID:       newBkt(map[string]int{lanaMugCode: 1}),
+			code:        "ONCE",
+			expectedErr: coupon.ErrCouponExhausted,
+		},
+		{
+			name:        "Apply coupon - Expired",
+			bktID:       newBkt(map[string]int{lanaMugCode: 1}),
+			code:        "OLD",
+			expectedErr: coupon.ErrCouponExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bkt, err := service.ApplyCoupon(tt.bktID, tt.code, 0)
+			if tt.expectedErr != nil {
+				require.True(t, errors.Is(err, tt.expectedErr))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, basket.NewMoney(tt.expectedSubtotal, basket.DefaultCurrency), bkt.Subtotal)
+			require.Equal(t, basket.NewMoney(tt.expectedAmount, basket.DefaultCurrency), bkt.Amount)
+
+			amount, err := service.GetAmount(tt.bktID)
+			require.NoError(t, err)
+			require.Equal(t, bkt.Amount, amount.Amount)
+			require.Len(t, amount.Discounts, 1)
+		})
+	}
+}
+
+func TestApplyCouponAfterBasketDiscounts(t *testing.T) {
+	dir := t.TempDir()
+	promotionsPath := filepath.Join(dir, "promotions.json")
+	err := ioutil.WriteFile(promotionsPath, []byte(`{"promotions": [
+		{"name": "five-off", "type": "basket_fixed", "amount": 5}
+	]}`), 0600)
+	require.NoError(t, err)
+	couponsPath := filepath.Join(dir, "coupons.json")
+	err = ioutil.WriteFile(couponsPath, []byte(`{"coupons": [
+		{"code": "MIN22", "type": "fixed", "amount": 2, "min_amount": 22, "max_redemptions": 1}
+	]}`), 0600)
+	require.NoError(t, err)
+	service, err := New(WithPromotionsFile(promotionsPath), WithCouponsFile(couponsPath))
+	require.NoError(t, err)
+
+	// 25€ of subtotal are 20€ after the basket discount, under the minimum of the coupon.
+	bkt := service.Create()
+	_, err = service.AddProduct(bkt.ID, lanaTshirtCode, 1, 0)
+	require.NoError(t, err)
+	_, err = service.AddProduct(bkt.ID, lanaPenCode, 1, 0)
+	require.NoError(t, err)
+	_, err = service.ApplyCoupon(bkt.ID, "MIN22", 0)
+	require.Equal(t, coupon.ErrMinAmountNotReached, err)
+
+	// The failed attempt does not use up the only redemption.
+	_, err = service.AddProduct(bkt.ID, lanaMugCode, 1, 0)
+	require.NoError(t, err)
+	bkt, err = service.ApplyCoupon(bkt.ID, "MIN22", 0)
+	require.NoError(t, err)
+	require.Equal(t, basket.NewMoney(3250, basket.DefaultCurrency), bkt.Subtotal)
+	require.Equal(t, basket.NewMoney(2550, basket.DefaultCurrency), bkt.Amount)
+}
+
+func TestRemoveCoupon(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "coupons.json")
+	err := ioutil.WriteFile(path, []byte(`{"coupons": [
+		{"code": "ONCE", "type": "percentage", "discount_percent": 10, "max_redemptions": 1}
+	]}`), 0600)
+	require.NoError(t, err)
+	service, err := New(WithCouponsFile(path))
+	require.NoError(t, err)
+
+	bkt := service.Create()
+	_, err = service.AddProduct(bkt.ID, lanaTshirtCode, 1, 0)
+	require.NoError(t, err)
+	_, err = service.RemoveCoupon(bkt.ID, 0)
+	require.Equal(t, ErrCouponNotApplied, err)
+
+	_, err = service.ApplyCoupon(bkt.ID, "ONCE", 0)
+	require.NoError(t, err)
+	removed, err := service.RemoveCoupon(bkt.ID, 0)
+	require.NoError(t, err)
+	require.Empty(t, removed.Coupon)
+	require.Empty(t, removed.Discounts)
+	require.Equal(t, basket.NewMoney(2000, basket.DefaultCurrency), removed.Amount)
+
+	// The redemption is only counted when paid, so another basket can use the coupon.
+	other := service.Create()
+	_, err = service.ApplyCoupon(other.ID, "ONCE", 0)
+	require.NoError(t, err)
+
+	_, err = service.RemoveCoupon("randomID", 0)
+	require.Equal(t, ErrBktNotFound, err)
+}
+
+func TestRedeemCouponOnPay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "coupons.json")
+	err := ioutil.WriteFile(path, []byte(`{"coupons": [
+		{"code": "ONCE", "type": "percentage", "discount_percent": 10, "max_redemptions": 1}
+	]}`), 0600)
+	require.NoError(t, err)
+	service, err := New(WithCouponsFile(path))
+	require.NoError(t, err)
+	withCoupon := func() string {
+		bkt := service.Create()
+		_, err := service.AddProduct(bkt.ID, lanaTshirtCode, 1, 0)
+		require.NoError(t, err)
+		_, err = service.ApplyCoupon(bkt.ID, "ONCE", 0)
+		require.NoError(t, err)
+		_, err = service.Checkout(bkt.ID, 0)
+		require.NoError(t, err)
+		return bkt.ID
+	}
+
+	// Baskets left unpaid do not use up the coupon.
+	first, second := withCoupon(), withCoupon()
+	_, err = service.Pay(first, 0)
+	require.NoError(t, err)
+	_, err = service.Pay(second, 0)
+	require.Equal(t, coupon.ErrCouponExhausted, err)
+	got, err := service.Get(second)
+	require.NoError(t, err)
+	require.Equal(t, statusCheckingOut, got.Status)
+
+	other := service.Create()
+	_, err = service.ApplyCoupon(other.ID, "ONCE", 0)
+	require.Equal(t, coupon.ErrCouponExhausted, err)
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestAddProductWithSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "promotions.json")
+	err := ioutil.WriteFile(path, []byte(`{"promotions": [
+		{"name": "mug-happy-hour", "type": "buy_x_or_more", "products": ["MUG"], "min_quantity": 1, "discount_percent": 50,
+		 "schedule": {"weekdays": ["friday"], "from": "17:00", "to": "19:00"}},
+		{"name": "pen-launch", "type": "buy_n_pay_m", "products": ["PEN"], "buy": 2, "pay": 1,
+		 "starts_at": "2024-03-01T00:00:00Z", "ends_at": "2024-03-08T00:00:00Z"}
+	]}`), 0600)
+	require.NoError(t, err)
+	clock := &fixedClock{}
+	service, err := New(WithPromotionsFile(path), WithClock(clock))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		now            time.Time
+		expectedAmount int64
+	}{
+		// 2024-03-01 is a Friday.
+		{name: "Schedule - Happy hour and launch week", now: time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC), expectedAmount: 875},
+		{name: "Schedule - Launch week", now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), expectedAmount: 1250},
+		{name: "Schedule - Happy hour after launch week", now: time.Date(2024, 3, 8, 18, 0, 0, 0, time.UTC), expectedAmount: 1375},
+		{name: "Schedule - Before launch week", now: time.Date(2024, 2, 29, 18, 0, 0, 0, time.UTC), expectedAmount: 1750},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.now = tt.now
+			bkt := service.Create()
+			_, err := service.AddProduct(bkt.ID, lanaPenCode, 2, 0)
+			require.NoError(t, err)
+			bkt, err = service.AddProduct(bkt.ID, lanaMugCode, 1, 0)
+			require.NoError(t, err)
+			require.Equal(t, basket.NewMoney(tt.expectedAmount, basket.DefaultCurrency), bkt.Amount)
+			require.Equal(t, tt.now.UTC().Format("01-02-2006 15:04:05"), bkt.DateLastUpdated)
+		})
+	}
+}
+
+func TestAddProductWithBasketDiscounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "promotions.json")
+	err := ioutil.WriteFile(path, []byte(`{"promotions": [
+		{"name": "pen-2x1", "type": "buy_n_pay_m", "products": ["PEN"], "buy": 2, "pay": 1},
+		{"name": "over-50", "type": "basket_percentage", "discount_percent": 10, "min_amount": 50},
+		{"name": "over-30", "type": "basket_fixed", "amount": 5, "min_amount": 30}
+	]}`), 0600)
+	require.NoError(t, err)
+	service, err := New(WithPromotionsFile(path))
+	require.NoError(t, err)
+
+	bkt := service.Create()
+	_, err = service.AddProduct(bkt.ID, lanaPenCode, 2, 0)
+	require.NoError(t, err)
+	bkt, err = service.AddProduct(bkt.ID, lanaTshirtCode, 3, 0)
+	require.NoError(t, err)
+	bkt, err = service.ApplyCoupon(bkt.ID, "LANA10", 0)
+	require.NoError(t, err)
+
+	// 65€ after the 2x1, 10% off is 6.50€, then 5€ off and the coupon takes 10% of the 53.50€ left.
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+	require.Equal(t, eur(6500), bkt.Subtotal)
+	require.Equal(t, []basket.Discount{
+		{Name: "over-50", Type: promotion.DiscountTypeBasket, Amount: eur(650)},
+		{Name: "over-30", Type: promotion.DiscountTypeBasket, Amount: eur(500)},
+		{Name: "LANA10", Type: discountCoupon, Amount: eur(535)},
+	}, bkt.Discounts)
+	require.Equal(t, eur(4815), bkt.Amount)
+
+	amount, err := service.GetAmount(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, bkt.Discounts, amount.Discounts)
+	require.Equal(t, bkt.Amount, amount.Amount)
+}
+
+func TestQuote(t *testing.T) {
+	service, err := New()
+	require.NoError(t, err)
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+	items := func(codes ...string) []basket.QuoteItem {
+		quoteItems := make([]basket.QuoteItem, 0, len(codes))
+		for _, code := range codes {
+			quoteItems = append(quoteItems, basket.QuoteItem{Code: code, Quantity: 1})
+		}
+		return quoteItems
+	}
+
+	tests := []struct {
+		name           string
+		items          []basket.QuoteItem
+		promotions     []promotion.Definition
+		expectedAmount int64
+		expectedErr    error
+	}{
+		{name: "Quote - PEN, TSHIRT, MUG", items: items(lanaPenCode, lanaTshirtCode, lanaMugCode), expectedAmount: 3250},
+		{name: "Quote - PEN, TSHIRT, PEN", items: items(lanaPenCode, lanaTshirtCode, lanaPenCode), expectedAmount: 2500},
+		{
+			name:           "Quote - PEN, TSHIRT, TSHIRT, TSHIRT, TSHIRT, MUG",
+			items:          items(lanaPenCode, lanaTshirtCode, lanaTshirtCode, lanaTshirtCode, lanaTshirtCode, lanaMugCode),
+			expectedAmount: 7250,
+		},
+		{
+			name:           "Quote - Quantities",
+			items:          []basket.QuoteItem{{Code: lanaPenCode, Quantity: 3}, {Code: lanaTshirtCode, Quantity: 3}, {Code: lanaMugCode, Quantity: 1}},
+			expectedAmount: 6250,
+		},
+		{
+			name:           "Quote - Promotions override",
+			items:          items(lanaMugCode, lanaMugCode, lanaPenCode, lanaPenCode),
+			promotions:     []promotion.Definition{{Name: "mug-2x1", Type: promotion.TypeBuyNPayM, Products: []string{lanaMugCode}, Buy: 2, Pay: 1}},
+			expectedAmount: 1750,
+		},
+		{
+			name:           "Quote - Without promotions",
+			items:          items(lanaPenCode, lanaPenCode),
+			promotions:     []promotion.Definition{},
+			expectedAmount: 1000,
+		},
+		{name: "Quote - Invalid product code", items: items("RANDOM"), expectedErr: ErrInvalidProductCode},
+		{name: "Quote - Invalid quantity", items: []basket.QuoteItem{{Code: lanaPenCode}}, expectedErr: ErrInvalidQuantity},
+		{
+			name:        "Quote - Too many units",
+			items:       []basket.QuoteItem{{Code: lanaPenCode, Quantity: maxQuantity}, {Code: lanaPenCode, Quantity: 1}},
+			expectedErr: ErrInvalidQuantity,
+		},
+		{
+			name:        "Quote - Promotion of an unknown product",
+			items:       items(lanaPenCode),
+			promotions:  []promotion.Definition{{Name: "x", Type: promotion.TypeBuyNPayM, Products: []string{"RANDOM"}, Buy: 2, Pay: 1}},
+			expectedErr: promotion.ErrInvalidPromotion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := service.Quote(tt.items, tt.promotions, time.Time{})
+			if tt.expectedErr != nil {
+				require.True(t, errors.Is(err, tt.expectedErr))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, eur(tt.expectedAmount), quote.Amount)
+		})
+	}
+	require.Empty(t, service.bktStorage)
+}
+
+func TestRepriceOnRead(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	service, err := New(WithClock(clock))
+	require.NoError(t, err)
+	bkt := service.Create()
+	_, err = service.AddProduct(bkt.ID, lanaMugCode, 2, 0)
+	require.NoError(t, err)
+	lockedAt := "03-01-2024 10:00:00"
+
+	// Reading an unchanged price keeps the lock.
+	clock.now = clock.now.Add(time.Hour)
+	amount, err := service.GetAmount(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, basket.NewMoney(1500, basket.DefaultCurrency), amount.Amount)
+	require.Equal(t, lockedAt, amount.PriceLockedAt)
+
+	// A new promotion is seen on the next read.
+	clock.now = clock.now.Add(time.Hour)
+	_, err = service.CreatePromotion(promotion.Definition{Name: "mug-2x1", Type: promotion.TypeBuyNPayM, Products: []string{lanaMugCode}, Buy: 2, Pay: 1})
+	require.NoError(t, err)
+	amount, err = service.GetAmount(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, basket.NewMoney(750, basket.DefaultCurrency), amount.Amount)
+	require.Equal(t, "03-01-2024 12:00:00", amount.PriceLockedAt)
+
+	clock.now = clock.now.Add(time.Hour)
+	stored, err := service.Get(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, amount.Amount, stored.Amount)
+	require.Equal(t, "03-01-2024 12:00:00", stored.PriceLockedAt)
+	require.Equal(t, lockedAt, stored.DateLastUpdated)
 }
@@ -0,0 +1,116 @@
+package local_map
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+)
+
+// Basket statuses. Open baskets can change, checking out ones have their contents and price frozen,
+// and paid, abandoned (expired) and deleted are final.
+const (
+	statusOpen        = "open"
+	statusCheckingOut = "checking_out"
+	statusPaid        = "paid"
+	statusAbandoned   = "abandoned"
+	statusDeleted     = "deleted"
+)
+
+var (
+	// ErrIllegalTransition is used when the basket can not move from its status to the requested one.
+	ErrIllegalTransition = errors.New("illegal basket status transition")
+	// ErrBasketNotOpen is used when changing the contents of a basket that is checking out or paid.
+	ErrBasketNotOpen = errors.New("basket is not open, its contents can not change")
+	// ErrBasketEmpty is used when checking out a basket without products.
+	ErrBasketEmpty = errors.New("basket is empty")
+
+	// transitions are the statuses each status can move to, the final ones have none.
+	transitions = map[string][]string{
+		statusOpen:        {statusCheckingOut, statusAbandoned, statusDeleted},
+		statusCheckingOut: {statusPaid, statusAbandoned, statusDeleted},
+	}
+)
+
+// checkTransition returns ErrIllegalTransition when a basket can not move from the status to the other one.
+func checkTransition(from, to string) error {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: from %s to %s", ErrIllegalTransition, from, to)
+}
+
+// found tells whether a basket in the status is still found, the abandoned and deleted ones are not.
+func found(status string) bool {
+	return status != statusAbandoned && status != statusDeleted
+}
+
+// Checkout freezes the contents and the price of the open basket until it is paid, deleted or it expires.
+func (s *Service) Checkout(bktID string, version int) (basket.Basket, error) {
+	s.bktMutex.Lock()
+	defer s.bktMutex.Unlock()
+	now := s.clock.Now()
+	bkt, err := s.lookupVersion(bktID, version, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	if err := checkTransition(bkt.Status, statusCheckingOut); err != nil {
+		return basket.Basket{}, err
+	}
+	if len(bkt.Products) == 0 {
+		return basket.Basket{}, ErrBasketEmpty
+	}
+
+	bkt = s.calculateAmount(bkt, now)
+	return s.moveTo(bkt, statusCheckingOut, now), nil
+}
+
+// Pay marks the basket checking out as paid, its reserved units are taken out of the stock of the catalog
+// and its coupon redeemed.
+func (s *Service) Pay(bktID string, version int) (basket.Basket, error) {
+	s.bktMutex.Lock()
+	defer s.bktMutex.Unlock()
+	now := s.clock.Now()
+	bkt, err := s.lookupVersion(bktID, version, now)
+	if err != nil {
+		return basket.Basket{}, err
+	}
+	if err := checkTransition(bkt.Status, statusPaid); err != nil {
+		return basket.Basket{}, err
+	}
+	// The coupon is redeemed when paid, it fails when the last redemption went to another basket meanwhile.
+	if bkt.Coupon != "" {
+		if err := s.coupons.Redeem(bkt.Coupon); err != nil {
+			return basket.Basket{}, err
+		}
+	}
+
+	for _, code := range sortedCodes(bkt.Products) {
+		s.consume(code, bkt.Products[code])
+	}
+	bkt.ExpiresAt = ""
+	return s.moveTo(bkt, statusPaid, now), nil
+}
+
+// moveTo stores the basket in the status as a new version. The caller holds bktMutex.
+func (s *Service) moveTo(bkt basket.Basket, status string, now time.Time) basket.Basket {
+	bkt.Status = status
+	bkt.Version++
+	bkt.DateLastUpdated = now.UTC().Format(dateLayout)
+	if status == statusCheckingOut {
+		bkt.ExpiresAt = s.expiresAt(now)
+	}
+	s.bktStorage[bkt.ID] = bkt
+	return bkt
+}
+
+// consume releases the units reserved by a paid basket and takes them out of the stock of the product,
+// products no longer in the catalog or without stock are only released. The caller holds bktMutex.
+func (s *Service) consume(code string, quantity int) {
+	s.release(code, quantity)
+	// A product deleted meanwhile is only released.
+	_ = s.catalog.Consume(code, quantity)
+}
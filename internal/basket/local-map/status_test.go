@@ -0,0 +1,137 @@
+package local_map
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from        string
+		to          string
+		expectedErr error
+	}{
+		{from: statusOpen, to: statusCheckingOut},
+		{from: statusOpen, to: statusAbandoned},
+		{from: statusOpen, to: statusDeleted},
+		{from: statusOpen, to: statusPaid, expectedErr: ErrIllegalTransition},
+		{from: statusCheckingOut, to: statusPaid},
+		{from: statusCheckingOut, to: statusAbandoned},
+		{from: statusCheckingOut, to: statusDeleted},
+		{from: statusCheckingOut, to: statusOpen, expectedErr: ErrIllegalTransition},
+		{from: statusCheckingOut, to: statusCheckingOut, expectedErr: ErrIllegalTransition},
+		{from: statusPaid, to: statusDeleted, expectedErr: ErrIllegalTransition},
+		{from: statusAbandoned, to: statusOpen, expectedErr: ErrIllegalTransition},
+		{from: statusDeleted, to: statusCheckingOut, expectedErr: ErrIllegalTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			require.True(t, errors.Is(checkTransition(tt.from, tt.to), tt.expectedErr))
+		})
+	}
+}
+
+func TestCheckout(t *testing.T) {
+	service := newStockService(t, 3)
+	bkt := service.Create()
+
+	_, err := service.Checkout(bkt.ID, 0)
+	require.Equal(t, ErrBasketEmpty, err)
+	_, err = service.Pay(bkt.ID, 0)
+	require.True(t, errors.Is(err, ErrIllegalTransition))
+
+	_, err = service.AddProduct(bkt.ID, lanaPenCode, 2, 0)
+	require.NoError(t, err)
+	bkt, err = service.Checkout(bkt.ID, 2)
+	require.NoError(t, err)
+	require.Equal(t, statusCheckingOut, bkt.Status)
+	require.Equal(t, 3, bkt.Version)
+	require.Equal(t, basket.NewMoney(500, basket.DefaultCurrency), bkt.Amount)
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "Checking out - Add product", run: func() error {
+			_, err := service.AddProduct(bkt.ID, lanaMugCode, 1, 0)
+			return err
+		}},
+		{name: "Checking out - Replace products", run: func() error {
+			_, err := service.ReplaceProducts(bkt.ID, map[string]int{}, 0)
+			return err
+		}},
+		{name: "Checking out - Apply coupon", run: func() error {
+			_, err := service.ApplyCoupon(bkt.ID, "LANA10", 0)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, ErrBasketNotOpen, tt.run())
+		})
+	}
+	_, err = service.Checkout(bkt.ID, 0)
+	require.True(t, errors.Is(err, ErrIllegalTransition))
+
+	// The price is frozen while checking out.
+	pen, err := service.Product(lanaPenCode)
+	require.NoError(t, err)
+	pen.Price = basket.NewMoney(900, basket.DefaultCurrency)
+	_, err = service.UpdateProduct(lanaPenCode, pen)
+	require.NoError(t, err)
+	got, err := service.Get(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, basket.NewMoney(500, basket.DefaultCurrency), got.Amount)
+
+	bkt, err = service.Pay(bkt.ID, 3)
+	require.NoError(t, err)
+	require.Equal(t, statusPaid, bkt.Status)
+	require.Empty(t, bkt.ExpiresAt)
+	require.Empty(t, service.reserved)
+	pen, err = service.Product(lanaPenCode)
+	require.NoError(t, err)
+	require.Equal(t, 1, *pen.Stock)
+
+	got, err = service.Get(bkt.ID)
+	require.NoError(t, err)
+	require.Equal(t, statusPaid, got.Status)
+	require.True(t, errors.Is(service.Delete(bkt.ID, 0), ErrIllegalTransition))
+	_, err = service.RemoveProduct(bkt.ID, lanaPenCode, 1, 0)
+	require.Equal(t, ErrBasketNotOpen, err)
+}
+
+func TestCheckoutDeleteAndExpiry(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2021, 11, 5, 10, 0, 0, 0, time.UTC)}
+	service := newStockService(t, 3, WithClock(clock), WithBasketTTL(30*time.Minute))
+
+	deleted := service.Create()
+	_, err := service.AddProduct(deleted.ID, lanaPenCode, 1, 0)
+	require.NoError(t, err)
+	_, err = service.Checkout(deleted.ID, 0)
+	require.NoError(t, err)
+	require.NoError(t, service.Delete(deleted.ID, 0))
+	_, err = service.Get(deleted.ID)
+	require.Equal(t, ErrBktNotFound, err)
+	require.Equal(t, statusDeleted, service.bktStorage[deleted.ID].Status)
+
+	abandoned := service.Create()
+	_, err = service.AddProduct(abandoned.ID, lanaPenCode, 2, 0)
+	require.NoError(t, err)
+	clock.now = clock.now.Add(20 * time.Minute)
+	abandoned, err = service.Checkout(abandoned.ID, 0)
+	require.NoError(t, err)
+	require.Equal(t, "11-05-2021 10:50:00", abandoned.ExpiresAt)
+
+	clock.now = clock.now.Add(30 * time.Minute)
+	require.Equal(t, 1, service.ExpireBaskets())
+	require.Equal(t, statusAbandoned, service.bktStorage[abandoned.ID].Status)
+	require.Empty(t, service.reserved)
+	_, err = service.Pay(abandoned.ID, 0)
+	require.Equal(t, ErrBktNotFound, err)
+}
@@ -0,0 +1,149 @@
+package local_map
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+	"github.com/stretchr/testify/require"
+)
+
+func newStockService(t *testing.T, stock int, opts ...Option) *Service {
+	products := defaultProducts()
+	products[0].Stock = &stock
+	store, err := catalog.NewMemory(products...)
+	require.NoError(t, err)
+	service, err := New(append([]Option{WithCatalog(store)}, opts...)...)
+	require.NoError(t, err)
+	return service
+}
+
+func TestAddProductWithStock(t *testing.T) {
+	service := newStockService(t, 3)
+	first := service.Create()
+	second := service.Create()
+
+	tests := []struct {
+		name        string
+		bktID       string
+		code        string
+		quantity    int
+		expectedErr error
+	}{
+		{name: "Stock - Ok - Reserved", bktID: first.ID, code: lanaPenCode, quantity: 2},
+		{name: "Stock - Out of stock", bktID: second.ID, code: lanaPenCode, quantity: 2, expectedErr: ErrOutOfStock},
+		{name: "Stock - Ok - Last unit", bktID: second.ID, code: lanaPenCode, quantity: 1},
+		{name: "Stock - Out of stock - None left", bktID: first.ID, code: lanaPenCode, quantity: 1, expectedErr: ErrOutOfStock},
+		{name: "Stock - Ok - Not tracked", bktID: first.ID, code: lanaMugCode, quantity: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.AddProduct(tt.bktID, tt.code, tt.quantity, 0)
+			require.True(t, errors.Is(err, tt.expectedErr))
+		})
+	}
+	require.Equal(t, map[string]int{lanaPenCode: 3, lanaMugCode: 100}, service.reserved)
+
+	_, err := service.RemoveProduct(first.ID, lanaMugCode, 40, 0)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{lanaPenCode: 3, lanaMugCode: 60}, service.reserved)
+	require.NoError(t, service.Delete(first.ID, 0))
+	require.Equal(t, map[string]int{lanaPenCode: 1}, service.reserved)
+	bkt, err := service.AddProduct(second.ID, lanaPenCode, 2, 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, bkt.Products[lanaPenCode])
+}
+
+func TestUpdateProductsWithStock(t *testing.T) {
+	service := newStockService(t, 3)
+	bkt := service.Create()
+
+	_, err := service.SetProductQuantity(bkt.ID, lanaPenCode, 2, 0)
+	require.NoError(t, err)
+	_, err = service.SetProductQuantity(bkt.ID, lanaPenCode, 2, 0)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{lanaPenCode: 2}, service.reserved)
+
+	_, err = service.SetProductQuantity(bkt.ID, lanaPenCode, 4, 0)
+	require.True(t, errors.Is(err, ErrOutOfStock))
+	_, err = service.ReplaceProducts(bkt.ID, map[string]int{lanaMugCode: 5, lanaPenCode: 4}, 0)
+	require.True(t, errors.Is(err, ErrOutOfStock))
+	require.Equal(t, map[string]int{lanaPenCode: 2}, service.reserved)
+
+	got, err := service.ReplaceProducts(bkt.ID, map[string]int{lanaMugCode: 5, lanaPenCode: 3}, 0)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{lanaMugCode: 5, lanaPenCode: 3}, got.Products)
+	require.Equal(t, map[string]int{lanaMugCode: 5, lanaPenCode: 3}, service.reserved)
+
+	_, err = service.ReplaceProducts(bkt.ID, map[string]int{lanaMugCode: 1}, 0)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{lanaMugCode: 1}, service.reserved)
+}
+
+func TestBasketExpiry(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2021, 11, 5, 10, 0, 0, 0, time.UTC)}
+	service := newStockService(t, 2, WithClock(clock), WithBasketTTL(30*time.Minute))
+
+	bkt := service.Create()
+	require.Equal(t, "11-05-2021 10:30:00", bkt.ExpiresAt)
+	clock.now = clock.now.Add(20 * time.Minute)
+	bkt, err := service.AddProduct(bkt.ID, lanaPenCode, 2, 0)
+	require.NoError(t, err)
+	require.Equal(t, "11-05-2021 10:50:00", bkt.ExpiresAt)
+
+	// Reading the basket does not extend its life.
+	clock.now = clock.now.Add(20 * time.Minute)
+	_, err = service.Get(bkt.ID)
+	require.NoError(t, err)
+	other := service.Create()
+	_, err = service.AddProduct(other.ID, lanaPenCode, 1, 0)
+	require.True(t, errors.Is(err, ErrOutOfStock))
+
+	clock.now = clock.now.Add(10 * time.Minute)
+	_, err = service.Get(bkt.ID)
+	require.Equal(t, ErrBktNotFound, err)
+	_, err = service.AddProduct(other.ID, lanaPenCode, 2, 0)
+	require.NoError(t, err)
+
+	clock.now = clock.now.Add(time.Hour)
+	require.Equal(t, 1, service.ExpireBaskets())
+	require.Empty(t, service.reserved)
+	require.Equal(t, 0, service.ExpireBaskets())
+}
+
+func TestAddProductWithStockConcurrently(t *testing.T) {
+	const stock = 50
+	service := newStockService(t, stock)
+	baskets := make([]basket.Basket, 2*stock)
+	for i := range baskets {
+		baskets[i] = service.Create()
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	reserved, outOfStock := 0, 0
+	for _, bkt := range baskets {
+		wg.Add(1)
+		go func(bktID string) {
+			defer wg.Done()
+			_, err := service.AddProduct(bktID, lanaPenCode, 1, 0)
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case err == nil:
+				reserved++
+			case errors.Is(err, ErrOutOfStock):
+				outOfStock++
+			default:
+				t.Error(err)
+			}
+		}(bkt.ID)
+	}
+	wg.Wait()
+	require.Equal(t, stock, reserved)
+	require.Equal(t, stock, outOfStock)
+}
@@ -0,0 +1,59 @@
+package local_map
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/mercadolibre/backend-challenge/internal/basket/catalog"
+	"github.com/mercadolibre/backend-challenge/internal/basket/promotion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddProductWithVariants(t *testing.T) {
+	products := defaultProducts()
+	for _, size := range []string{"S", "M", "L"} {
+		products = append(products, basket.Product{
+			Code:       lanaTshirtCode + "-" + size,
+			Name:       "Lana T-Shirt " + size,
+			Price:      basket.NewMoney(2000, basket.DefaultCurrency),
+			Parent:     lanaTshirtCode,
+			Attributes: map[string]string{"size": size},
+		})
+	}
+	store, err := catalog.NewMemory(products...)
+	require.NoError(t, err)
+	service, err := New(WithCatalog(store))
+	require.NoError(t, err)
+	bkt := service.Create()
+
+	tests := []struct {
+		name           string
+		code           string
+		expectedAmount int64
+		expectedErr    error
+	}{
+		{name: "Variants - Parent can not be added", code: lanaTshirtCode, expectedErr: ErrVariantRequired},
+		{name: "Variants - Ok - First size", code: "TSHIRT-S", expectedAmount: 2000},
+		{name: "Variants - Ok - Second size", code: "TSHIRT-M", expectedAmount: 4000},
+		{name: "Variants - Ok - Bulk across sizes", code: "TSHIRT-L", expectedAmount: 4500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.AddProduct(bkt.ID, tt.code, 1, 0)
+			if tt.expectedErr != nil {
+				require.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, basket.NewMoney(tt.expectedAmount, basket.DefaultCurrency), got.Amount)
+		})
+	}
+
+	_, err = service.Quote([]basket.QuoteItem{{Code: lanaTshirtCode, Quantity: 1}}, nil, time.Time{})
+	require.Equal(t, ErrVariantRequired, err)
+	_, err = service.CreatePromotion(promotion.Definition{Name: "small-2x1", Type: promotion.TypeBuyNPayM, Products: []string{"TSHIRT-S"}, Buy: 2, Pay: 1})
+	require.True(t, errors.Is(err, promotion.ErrInvalidPromotion))
+}
@@ -1,13 +1,29 @@
 package basket
 
+import "encoding/json"
+
 // Basket represents the Basket response.
 type Basket struct {
-	ID              string         `json:"id"`
-	Products        map[string]int `json:"products"`
-	Amount          float64        `json:"total_amount"`
-	DateCreated     string         `json:"date_created"`
-	DateLastUpdated string         `json:"date_last_updated"`
-	Status          string         `json:"-"` // Active or Inactive
+	ID              string             `json:"id"`
+	Products        map[string]int     `json:"products"`
+	Items           []LineItem         `json:"items"`
+	Subtotal        Money              `json:"subtotal_amount"`
+	Discounts       []Discount         `json:"discounts"`
+	Amount          Money              `json:"total_amount"`
+	Promotions      []AppliedPromotion `json:"applied_promotions"`
+	Coupon          string             `json:"coupon,omitempty"`
+	Currency        string             `json:"currency"`
+	FormattedAmount string             `json:"formatted_total_amount,omitempty"`
+	DateCreated     string             `json:"date_created"`
+	DateLastUpdated string             `json:"date_last_updated"`
+	// PriceLockedAt is when the basket got its current price, it is repriced lazily on read.
+	PriceLockedAt string `json:"price_locked_at"`
+	// ExpiresAt is when the basket expires and its stock is released unless it is updated before, empty if it never does.
+	ExpiresAt string `json:"expires_at,omitempty"`
+	// Version counts the changes of the basket, the mutations take the version the caller read or zero to skip the check.
+	Version int `json:"version"`
+	// Status is open, checking_out, paid, abandoned or deleted, only open baskets can change their contents.
+	Status string `json:"status"`
 }
 
 // AddProduct represents the AddProduct request.
@@ -16,15 +32,103 @@ type AddProduct struct {
 	Quantity int    `json:"quantity"`
 }
 
+// SetQuantity represents the SetProductQuantity request, zero removes the product.
+type SetQuantity struct {
+	Quantity *int `json:"quantity"`
+}
+
+// ReplaceProducts represents the ReplaceProducts request, the units of each product code.
+type ReplaceProducts struct {
+	Products map[string]int `json:"products"`
+}
+
 // Product is used to store the information of each product.
 type Product struct {
-	Code  string  `json:"code"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Price    Money  `json:"price"`
+	Category string `json:"category,omitempty"`
+	// Tags are other categories of the product, promotions targeting any of them apply to it.
+	Tags []string `json:"tags,omitempty"`
+	// Stock is the number of units that can be reserved by the baskets, the stock is not tracked when nil.
+	Stock *int `json:"stock,omitempty"`
+	// Parent is the code of the product this one is a variant of, like a size of a t-shirt.
+	// The line promotions of the parent count the units of all its variants together.
+	Parent string `json:"parent,omitempty"`
+	// Attributes tell the variants apart, e.g. {"size": "M", "colour": "red"}.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// ApplyCoupon represents the ApplyCoupon request.
+type ApplyCoupon struct {
+	Code string `json:"code"`
 }
 
 // GetAmount represents the GetAmount response.
+// Amount is the Subtotal after promotions minus the basket level Discounts.
 type GetAmount struct {
-	BktID  string  `json:"basket_id"`
-	Amount float64 `json:"amount"`
+	BktID           string     `json:"basket_id"`
+	Subtotal        Money      `json:"subtotal"`
+	Discounts       []Discount `json:"discounts"`
+	Amount          Money      `json:"amount"`
+	Currency        string     `json:"currency"`
+	FormattedAmount string     `json:"formatted_amount,omitempty"`
+	PriceLockedAt   string     `json:"price_locked_at,omitempty"`
+	// Version is sent as the ETag header.
+	Version int `json:"-"`
+}
+
+// QuoteItem is a product of a quote request, written as {"code": "PEN", "quantity": 2} or just "PEN" for one unit.
+type QuoteItem struct {
+	Code     string `json:"code"`
+	Quantity int    `json:"quantity"`
+}
+
+// UnmarshalJSON accepts a product code as a single unit or the code with its quantity.
+func (i *QuoteItem) UnmarshalJSON(data []byte) error {
+	var code string
+	if err := json.Unmarshal(data, &code); err == nil {
+		*i = QuoteItem{Code: code, Quantity: 1}
+		return nil
+	}
+	type item QuoteItem
+	return json.Unmarshal(data, (*item)(i))
+}
+
+// Quote represents the Quote response, the price of the products without creating a basket.
+type Quote struct {
+	Items           []LineItem         `json:"items"`
+	Subtotal        Money              `json:"subtotal_amount"`
+	Discounts       []Discount         `json:"discounts"`
+	Amount          Money              `json:"total_amount"`
+	Promotions      []AppliedPromotion `json:"applied_promotions"`
+	Currency        string             `json:"currency"`
+	FormattedAmount string             `json:"formatted_total_amount,omitempty"`
+}
+
+// AppliedPromotion is a promotion chosen to price a basket, with the units it priced and the discount it gave.
+type AppliedPromotion struct {
+	Name     string         `json:"name"`
+	Products map[string]int `json:"products"`
+	Discount Money          `json:"discount"`
+}
+
+// LineItem is the price breakdown of a product in the basket.
+// Discount adds up the share of every promotion that priced units of the product, so Net is Gross minus Discount.
+type LineItem struct {
+	Code       string   `json:"code"`
+	Name       string   `json:"name"`
+	UnitPrice  Money    `json:"unit_price"`
+	Quantity   int      `json:"quantity"`
+	Gross      Money    `json:"gross"`
+	Discount   Money    `json:"discount"`
+	Promotions []string `json:"promotions,omitempty"`
+	Net        Money    `json:"net"`
+}
+
+// Discount is a basket level discount applied over the subtotal, like a coupon.
+type Discount struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Amount Money  `json:"amount"`
 }
@@ -0,0 +1,178 @@
+package basket
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code used when none is provided.
+const DefaultCurrency = "EUR"
+
+// basisPointsPerUnit is the number of basis points in 100%.
+const basisPointsPerUnit = 10000
+
+// currencyExponents keeps the ISO 4217 minor units of the currencies that do not use two decimals.
+var currencyExponents = map[string]int{
+	"CLP": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+var (
+	// ErrInvalidMoney is used when a decimal amount can not be represented in the currency minor units.
+	ErrInvalidMoney = errors.New("invalid money amount")
+)
+
+// Money is an amount expressed in the minor units of its currency (cents for EUR).
+//
+// All the arithmetic is done over integers. Operations that can produce fractions of a
+// minor unit (percentages) round half away from zero to the nearest minor unit.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney returns a Money of amount minor units in the given currency.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal string like "7.50" into a Money of the given currency.
+// It fails when the value has more decimals than the currency minor units.
+func ParseMoney(value string, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Money{}, ErrInvalidMoney
+	}
+	exp := exponent(currency)
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	units, decimals := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		units, decimals = value[:i], value[i+1:]
+	}
+	if units == "" || len(decimals) > exp || strings.ContainsAny(units+decimals, "+-") {
+		return Money{}, ErrInvalidMoney
+	}
+	decimals += strings.Repeat("0", exp-len(decimals))
+
+	amount, err := strconv.ParseInt(units+decimals, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidMoney
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Add returns the sum of both amounts.
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.currencyWith(other)}
+}
+
+// Sub returns the difference of both amounts.
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.currencyWith(other)}
+}
+
+// Mul returns the amount multiplied by quantity.
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Percent returns basisPoints/10000 of the amount (2500 is 25%), rounded half away from zero.
+func (m Money) Percent(basisPoints int64) Money {
+	return Money{Amount: divRound(m.Amount*basisPoints, basisPointsPerUnit), Currency: m.Currency}
+}
+
+// BasisPoints converts a percentage with up to two decimals (12.5) to basis points (1250).
+func BasisPoints(percent float64) (int64, error) {
+	if percent <= 0 || percent > 100 {
+		return 0, errors.New("discount_percent must be greater than 0 and up to 100")
+	}
+	bp := math.Round(percent * 100)
+	if math.Abs(bp-percent*100) > 1e-6 {
+		return 0, errors.New("discount_percent supports up to two decimals")
+	}
+	return int64(bp), nil
+}
+
+// Decimals returns the number of minor unit digits of the currency.
+func (m Money) Decimals() int {
+	return exponent(m.Currency)
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// String returns the decimal representation of the amount, e.g. "62.50".
+func (m Money) String() string {
+	exp := exponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	digits := fmt.Sprintf("%0*d", exp+1, amount)
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// MarshalJSON writes the amount as a JSON number with the currency decimals, e.g. 62.50,
+// so clients that used to read a float keep working.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or string. The currency is left empty, the caller
+// decides which one applies.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	parsed, err := ParseMoney(value, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) currencyWith(other Money) string {
+	if m.Currency == "" {
+		return other.Currency
+	}
+	return m.Currency
+}
+
+func exponent(currency string) int {
+	if exp, ok := currencyExponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// divRound divides rounding half away from zero.
+func divRound(numerator, denominator int64) int64 {
+	quotient, remainder := numerator/denominator, numerator%denominator
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if remainder*2 >= denominator {
+		if numerator < 0 {
+			return quotient - 1
+		}
+		return quotient + 1
+	}
+	return quotient
+}
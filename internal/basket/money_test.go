@@ -0,0 +1,143 @@
+package basket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		currency      string
+		expected      Money
+		expectedError error
+	}{
+		{
+			name:     "Parse money - Ok - Two decimals",
+			value:    "7.50",
+			currency: DefaultCurrency,
+			expected: NewMoney(750, DefaultCurrency),
+		},
+		{
+			name:     "Parse money - Ok - One decimal",
+			value:    "62.5",
+			currency: DefaultCurrency,
+			expected: NewMoney(6250, DefaultCurrency),
+		},
+		{
+			name:     "Parse money - Ok - No decimals",
+			value:    "20",
+			currency: DefaultCurrency,
+			expected: NewMoney(2000, DefaultCurrency),
+		},
+		{
+			name:     "Parse money - Ok - Negative",
+			value:    "-0.05",
+			currency: DefaultCurrency,
+			expected: NewMoney(-5, DefaultCurrency),
+		},
+		{
+			name:     "Parse money - Ok - Currency without decimals",
+			value:    "500",
+			currency: "JPY",
+			expected: NewMoney(500, "JPY"),
+		},
+		{
+			name:          "Parse money - Error - Too many decimals",
+			value:         "7.505",
+			currency:      DefaultCurrency,
+			expectedError: ErrInvalidMoney,
+		},
+		{
+			name:          "Parse money - Error - Exponent notation",
+			value:         "1e3",
+			currency:      DefaultCurrency,
+			expectedError: ErrInvalidMoney,
+		},
+		{
+			name:          "Parse money - Error - Empty",
+			value:         "",
+			currency:      DefaultCurrency,
+			expectedError: ErrInvalidMoney,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := ParseMoney(tt.value, tt.currency)
+			require.Equal(t, tt.expectedError, err)
+			require.Equal(t, tt.expected, money)
+		})
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      int64
+		basisPoints int64
+		expected    int64
+	}{
+		{name: "Percent - Exact", amount: 6000, basisPoints: 2500, expected: 1500},
+		{name: "Percent - Round half up", amount: 750, basisPoints: 3333, expected: 250},
+		{name: "Percent - Round down", amount: 101, basisPoints: 1000, expected: 10},
+		{name: "Percent - Half cent rounds away from zero", amount: 5, basisPoints: 5000, expected: 3},
+		{name: "Percent - Negative half cent rounds away from zero", amount: -5, basisPoints: 5000, expected: -3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewMoney(tt.amount, DefaultCurrency).Percent(tt.basisPoints)
+			require.Equal(t, NewMoney(tt.expected, DefaultCurrency), result)
+		})
+	}
+}
+
+func TestBasisPoints(t *testing.T) {
+	tests := []struct {
+		name      string
+		percent   float64
+		expected  int64
+		expectErr bool
+	}{
+		{name: "BasisPoints - Two decimals", percent: 12.5, expected: 1250},
+		{name: "BasisPoints - All", percent: 100, expected: 10000},
+		{name: "BasisPoints - More than two decimals", percent: 12.345, expectErr: true},
+		{name: "BasisPoints - Zero", percent: 0, expectErr: true},
+		{name: "BasisPoints - Over 100", percent: 100.01, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := BasisPoints(tt.percent)
+			require.Equal(t, tt.expectErr, err != nil)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(GetAmount{
+		BktID:     "id",
+		Subtotal:  NewMoney(6250, DefaultCurrency),
+		Discounts: []Discount{},
+		Amount:    NewMoney(6250, DefaultCurrency),
+		Currency:  DefaultCurrency,
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"basket_id":"id","subtotal":62.50,"discounts":[],"amount":62.50,"currency":"EUR"}`, string(data))
+
+	var product Product
+	err = json.Unmarshal([]byte(`{"code":"MUG","price":7.5}`), &product)
+	require.NoError(t, err)
+	require.Equal(t, int64(750), product.Price.Amount)
+
+	err = json.Unmarshal([]byte(`{"code":"MUG","price":"7.50"}`), &product)
+	require.NoError(t, err)
+	require.Equal(t, int64(750), product.Price.Amount)
+
+	require.Equal(t, "-0.05", NewMoney(-5, DefaultCurrency).String())
+}
@@ -0,0 +1,67 @@
+package promotion
+
+import (
+	"sort"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+)
+
+// DiscountTypeBasket is the type of the basket.Discount given by a BasketDiscount.
+const DiscountTypeBasket = "basket"
+
+// BasketDiscount is an order level promotion, like 10% off baskets over 50€.
+// It is applied over the amount left by the line promotions and the basket discounts with a lower Priority.
+type BasketDiscount struct {
+	Name string
+	// Priority sorts the basket discounts, the lower the sooner. Ties keep the configuration order.
+	Priority int
+	// MinAmount is the amount the basket must reach, once the previous discounts are applied.
+	MinAmount basket.Money
+	// Percent is the discount in basis points, used by basket_percentage discounts.
+	Percent int64
+	// Amount is the discount of basket_fixed discounts.
+	Amount basket.Money
+	// MaxDiscount caps the discount when it is set.
+	MaxDiscount *basket.Money
+	Schedule    *Schedule
+}
+
+// Discount returns the discount over amount, never greater than amount nor than MaxDiscount.
+func (d BasketDiscount) Discount(amount basket.Money) basket.Money {
+	if amount.Amount <= 0 || amount.Amount < d.MinAmount.Amount {
+		return basket.NewMoney(0, amount.Currency)
+	}
+	discount := basket.NewMoney(d.Amount.Amount, amount.Currency)
+	if d.Percent > 0 {
+		discount = amount.Percent(d.Percent)
+	}
+	if d.MaxDiscount != nil && discount.Amount > d.MaxDiscount.Amount {
+		discount = basket.NewMoney(d.MaxDiscount.Amount, amount.Currency)
+	}
+	if discount.Amount > amount.Amount {
+		return amount
+	}
+	return discount
+}
+
+// ApplyDiscounts applies the basket discounts of the set in Priority order over subtotal,
+// it returns the discounts given and the amount left.
+func (s Set) ApplyDiscounts(subtotal basket.Money) ([]basket.Discount, basket.Money) {
+	discounts := []basket.Discount{}
+	amount := subtotal
+	for _, rule := range s.Discounts {
+		discount := rule.Discount(amount)
+		if discount.Amount <= 0 {
+			continue
+		}
+		discounts = append(discounts, basket.Discount{Name: rule.Name, Type: DiscountTypeBasket, Amount: discount})
+		amount = amount.Sub(discount)
+	}
+	return discounts, amount
+}
+
+func sortDiscounts(discounts []BasketDiscount) {
+	sort.SliceStable(discounts, func(i, j int) bool {
+		return discounts[i].Priority < discounts[j].Priority
+	})
+}
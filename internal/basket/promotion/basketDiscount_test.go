@@ -0,0 +1,102 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetApplyDiscounts(t *testing.T) {
+	money := func(amount int64) *basket.Money {
+		m := basket.NewMoney(amount, "")
+		return &m
+	}
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+	over50 := Definition{Name: "over-50", Type: TypeBasketPercentage, DiscountPercent: 10, MinAmount: money(5000)}
+	over30 := Definition{Name: "over-30", Type: TypeBasketFixed, Amount: money(500), MinAmount: money(3000)}
+
+	tests := []struct {
+		name              string
+		definitions       []Definition
+		subtotal          int64
+		expectedDiscounts []basket.Discount
+		expectedAmount    int64
+	}{
+		{
+			name:              "Discounts - Below every minimum",
+			definitions:       []Definition{over50, over30},
+			subtotal:          2999,
+			expectedDiscounts: []basket.Discount{},
+			expectedAmount:    2999,
+		},
+		{
+			name:              "Discounts - Fixed over 30",
+			definitions:       []Definition{over50, over30},
+			subtotal:          4000,
+			expectedDiscounts: []basket.Discount{{Name: "over-30", Type: DiscountTypeBasket, Amount: eur(500)}},
+			expectedAmount:    3500,
+		},
+		{
+			name:        "Discounts - Both in configuration order",
+			definitions: []Definition{over50, over30},
+			subtotal:    6000,
+			expectedDiscounts: []basket.Discount{
+				{Name: "over-50", Type: DiscountTypeBasket, Amount: eur(600)},
+				{Name: "over-30", Type: DiscountTypeBasket, Amount: eur(500)},
+			},
+			expectedAmount: 4900,
+		},
+		{
+			name: "Discounts - Priority puts the fixed discount first",
+			definitions: []Definition{
+				over50,
+				{Name: "over-30", Type: TypeBasketFixed, Amount: money(500), MinAmount: money(3000), Priority: -1},
+			},
+			subtotal: 6000,
+			expectedDiscounts: []basket.Discount{
+				{Name: "over-30", Type: DiscountTypeBasket, Amount: eur(500)},
+				{Name: "over-50", Type: DiscountTypeBasket, Amount: eur(550)},
+			},
+			expectedAmount: 4950,
+		},
+		{
+			name: "Discounts - Minimum checked after the previous discounts",
+			definitions: []Definition{
+				{Name: "first", Type: TypeBasketFixed, Amount: money(1000)},
+				over50,
+			},
+			subtotal:          5500,
+			expectedDiscounts: []basket.Discount{{Name: "first", Type: DiscountTypeBasket, Amount: eur(1000)}},
+			expectedAmount:    4500,
+		},
+		{
+			name: "Discounts - Capped",
+			definitions: []Definition{
+				{Name: "half", Type: TypeBasketPercentage, DiscountPercent: 50, MaxDiscount: money(2000)},
+			},
+			subtotal:          10000,
+			expectedDiscounts: []basket.Discount{{Name: "half", Type: DiscountTypeBasket, Amount: eur(2000)}},
+			expectedAmount:    8000,
+		},
+		{
+			name: "Discounts - Never below zero",
+			definitions: []Definition{
+				{Name: "ten-off", Type: TypeBasketFixed, Amount: money(1000)},
+			},
+			subtotal:          700,
+			expectedDiscounts: []basket.Discount{{Name: "ten-off", Type: DiscountTypeBasket, Amount: eur(700)}},
+			expectedAmount:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := Config{Promotions: tt.definitions}.Build()
+			require.NoError(t, err)
+			discounts, amount := set.ApplyDiscounts(eur(tt.subtotal))
+			require.Equal(t, tt.expectedDiscounts, discounts)
+			require.Equal(t, eur(tt.expectedAmount), amount)
+		})
+	}
+}
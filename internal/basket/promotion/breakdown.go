@@ -0,0 +1,50 @@
+package promotion
+
+import "github.com/mercadolibre/backend-challenge/internal/basket"
+
+// lineItems itemizes the products with the discounts of the applied promotions.
+// The discount of a promotion that priced several products (bundles) is split proportionally
+// to their gross amount rounding down, the remainder goes to the last product by code.
+func lineItems(products map[string]int, catalog map[string]basket.Product, currency string, applied []basket.AppliedPromotion) []basket.LineItem {
+	withoutPromo := WithoutPromo{}
+	items := make([]basket.LineItem, 0, len(products))
+	index := make(map[string]int, len(products))
+	for _, code := range sortedCodes(products) {
+		quantity := products[code]
+		if quantity <= 0 {
+			continue
+		}
+		product := catalog[code]
+		gross := withoutPromo.Compute(product, quantity)
+		index[code] = len(items)
+		items = append(items, basket.LineItem{
+			Code:      code,
+			Name:      product.Name,
+			UnitPrice: product.Price,
+			Quantity:  quantity,
+			Gross:     gross,
+			Discount:  basket.NewMoney(0, currency),
+			Net:       gross,
+		})
+	}
+
+	for _, promo := range applied {
+		codes := sortedCodes(promo.Products)
+		total := listPrice(promo.Products, catalog, currency)
+		left := promo.Discount
+		for i, code := range codes {
+			share := left
+			if i < len(codes)-1 && total.Amount > 0 {
+				gross := withoutPromo.Compute(catalog[code], promo.Products[code])
+				share = basket.NewMoney(promo.Discount.Amount*gross.Amount/total.Amount, currency)
+			}
+			left = left.Sub(share)
+
+			item := &items[index[code]]
+			item.Discount = item.Discount.Add(share)
+			item.Net = item.Gross.Sub(item.Discount)
+			item.Promotions = append(item.Promotions, promo.Name)
+		}
+	}
+	return items
+}
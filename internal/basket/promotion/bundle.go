@@ -0,0 +1,42 @@
+package promotion
+
+import "github.com/mercadolibre/backend-challenge/internal/basket"
+
+// Bundle is responsible for promotion methods.
+// Each application takes Items units of every product and charges Price for all of them.
+// When MaxApplications is greater than zero the bundle is applied at most that number of times.
+type Bundle struct {
+	Items           map[string]int
+	Price           basket.Money
+	MaxApplications int
+}
+
+// Apply returns the price of the bundles found in products, up to limit, and the units they take.
+func (s *Bundle) Apply(products map[string]int, catalog map[string]basket.Product, limit int) (basket.Money, map[string]int) {
+	times := -1
+	currency := s.Price.Currency
+	for code, units := range s.Items {
+		available := products[code] / units
+		if times < 0 || available < times {
+			times = available
+		}
+		if currency == "" {
+			currency = catalog[code].Price.Currency
+		}
+	}
+	if s.MaxApplications > 0 && times > s.MaxApplications {
+		times = s.MaxApplications
+	}
+	if times > limit {
+		times = limit
+	}
+	if times <= 0 {
+		return basket.NewMoney(0, currency), nil
+	}
+
+	consumed := make(map[string]int, len(s.Items))
+	for code, units := range s.Items {
+		consumed[code] = units * times
+	}
+	return basket.NewMoney(s.Price.Amount, currency).Mul(times), consumed
+}
@@ -0,0 +1,79 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleApply(t *testing.T) {
+	catalog := map[string]basket.Product{
+		"PEN":    {Code: "PEN", Price: basket.NewMoney(500, basket.DefaultCurrency)},
+		"TSHIRT": {Code: "TSHIRT", Price: basket.NewMoney(2000, basket.DefaultCurrency)},
+		"MUG":    {Code: "MUG", Price: basket.NewMoney(750, basket.DefaultCurrency)},
+	}
+	bundle := Bundle{Items: map[string]int{"PEN": 1, "TSHIRT": 1, "MUG": 1}, Price: basket.NewMoney(2500, "")}
+	tests := []struct {
+		name             string
+		bundle           Bundle
+		products         map[string]int
+		limit            int
+		expectedPrice    int64
+		expectedConsumed map[string]int
+	}{
+		{
+			name:     "Bundle - Missing product",
+			bundle:   bundle,
+			products: map[string]int{"PEN": 2, "TSHIRT": 1},
+		},
+		{
+			name:             "Bundle - One application",
+			bundle:           bundle,
+			products:         map[string]int{"PEN": 3, "TSHIRT": 1, "MUG": 2},
+			expectedPrice:    2500,
+			expectedConsumed: map[string]int{"PEN": 1, "TSHIRT": 1, "MUG": 1},
+		},
+		{
+			name:             "Bundle - Two applications",
+			bundle:           bundle,
+			products:         map[string]int{"PEN": 2, "TSHIRT": 2, "MUG": 3},
+			expectedPrice:    5000,
+			expectedConsumed: map[string]int{"PEN": 2, "TSHIRT": 2, "MUG": 2},
+		},
+		{
+			name:             "Bundle - Max applications",
+			bundle:           Bundle{Items: bundle.Items, Price: bundle.Price, MaxApplications: 1},
+			products:         map[string]int{"PEN": 2, "TSHIRT": 2, "MUG": 2},
+			expectedPrice:    2500,
+			expectedConsumed: map[string]int{"PEN": 1, "TSHIRT": 1, "MUG": 1},
+		},
+		{
+			name:             "Bundle - Limit",
+			bundle:           bundle,
+			products:         map[string]int{"PEN": 2, "TSHIRT": 2, "MUG": 2},
+			limit:            1,
+			expectedPrice:    2500,
+			expectedConsumed: map[string]int{"PEN": 1, "TSHIRT": 1, "MUG": 1},
+		},
+		{
+			name:             "Bundle - Several units of a product",
+			bundle:           Bundle{Items: map[string]int{"PEN": 2, "MUG": 1}, Price: basket.NewMoney(1000, "")},
+			products:         map[string]int{"PEN": 5, "MUG": 3},
+			expectedPrice:    2000,
+			expectedConsumed: map[string]int{"PEN": 4, "MUG": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := tt.limit
+			if limit == 0 {
+				limit = unlimited
+			}
+			price, consumed := tt.bundle.Apply(tt.products, catalog, limit)
+			require.Equal(t, basket.NewMoney(tt.expectedPrice, basket.DefaultCurrency), price)
+			require.Equal(t, tt.expectedConsumed, consumed)
+		})
+	}
+}
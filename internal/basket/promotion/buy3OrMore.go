@@ -2,18 +2,24 @@ package promotion
 
 import "github.com/mercadolibre/backend-challenge/internal/basket"
 
-const (
-	lanaTshirtQuantityStrategy = 3
-	lanaTshirtDiscount         = 0.25
-)
-
 // BuyXOrMore is responsible for promotion methods.
-type BuyXOrMore struct{}
+// Buying MinQuantity or more units reduces the price of each unit by Discount basis points (2500 is 25%).
+type BuyXOrMore struct {
+	MinQuantity int
+	Discount    int64
+}
 
 // Compute calculate the amount for buyXOrMore promotion.
-func (s *BuyXOrMore) Compute(product basket.Product, quantity int) float64 {
-	if quantity >= lanaTshirtQuantityStrategy {
-		return (product.Price * float64(quantity)) - ((product.Price * float64(quantity)) * lanaTshirtDiscount)
+// The discount is computed over the line total, so it is rounded only once.
+func (s *BuyXOrMore) Compute(product basket.Product, quantity int) basket.Money {
+	gross := product.Price.Mul(quantity)
+	if quantity >= s.MinQuantity {
+		return gross.Sub(gross.Percent(s.Discount))
 	}
-	return product.Price * float64(quantity)
+	return gross
+}
+
+// Steps changes at MinQuantity, every unit costs the same at both sides.
+func (s *BuyXOrMore) Steps() ([]int, int) {
+	return []int{s.MinQuantity}, 1
 }
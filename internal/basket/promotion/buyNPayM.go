@@ -0,0 +1,30 @@
+package promotion
+
+import "github.com/mercadolibre/backend-challenge/internal/basket"
+
+// BuyNPayM is responsible for promotion methods.
+// Every group of Buy units costs Pay units (2x1, 3x2, 4x3...). When MaxApplications is greater
+// than zero, at most that number of groups get the promotion and the rest is charged at full price.
+type BuyNPayM struct {
+	Buy             int
+	Pay             int
+	MaxApplications int
+}
+
+// Compute calculate the amount for buyNPayM promotion.
+func (s *BuyNPayM) Compute(product basket.Product, quantity int) basket.Money {
+	groups := quantity / s.Buy
+	if s.MaxApplications > 0 && groups > s.MaxApplications {
+		groups = s.MaxApplications
+	}
+	charged := quantity - groups*(s.Buy-s.Pay)
+	return product.Price.Mul(charged)
+}
+
+// Steps repeats every Buy units, until MaxApplications groups are reached.
+func (s *BuyNPayM) Steps() ([]int, int) {
+	if s.MaxApplications > 0 {
+		return []int{s.Buy * s.MaxApplications}, s.Buy
+	}
+	return nil, s.Buy
+}
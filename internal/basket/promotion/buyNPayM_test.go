@@ -0,0 +1,37 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuyNPayMCompute(t *testing.T) {
+	pen := basket.Product{Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(500, basket.DefaultCurrency)}
+	tests := []struct {
+		name     string
+		promo    BuyNPayM
+		quantity int
+		expected int64
+	}{
+		// Items: PEN, TSHIRT, MUG
+		{name: "2x1 - One unit", promo: BuyNPayM{Buy: 2, Pay: 1}, quantity: 1, expected: 500},
+		// Items: PEN, TSHIRT, PEN
+		{name: "2x1 - Two units", promo: BuyNPayM{Buy: 2, Pay: 1}, quantity: 2, expected: 500},
+		// Items: PEN, TSHIRT, PEN, PEN, MUG, TSHIRT, TSHIRT
+		{name: "2x1 - Three units", promo: BuyNPayM{Buy: 2, Pay: 1}, quantity: 3, expected: 1000},
+		{name: "2x1 - Zero units", promo: BuyNPayM{Buy: 2, Pay: 1}, quantity: 0, expected: 0},
+		{name: "3x2 - Two units", promo: BuyNPayM{Buy: 3, Pay: 2}, quantity: 2, expected: 1000},
+		{name: "3x2 - Seven units", promo: BuyNPayM{Buy: 3, Pay: 2}, quantity: 7, expected: 2500},
+		{name: "4x3 - Eight units", promo: BuyNPayM{Buy: 4, Pay: 3}, quantity: 8, expected: 3000},
+		{name: "3x2 - Max one application", promo: BuyNPayM{Buy: 3, Pay: 2, MaxApplications: 1}, quantity: 6, expected: 2500},
+		{name: "2x1 - Max applications not reached", promo: BuyNPayM{Buy: 2, Pay: 1, MaxApplications: 5}, quantity: 4, expected: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, basket.NewMoney(tt.expected, basket.DefaultCurrency), tt.promo.Compute(pen, tt.quantity))
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package promotion
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+)
+
+// Supported promotion types.
+const (
+	TypeBuyNPayM   = "buy_n_pay_m"
+	TypeBuyXOrMore = "buy_x_or_more"
+	TypeTiered     = "tiered"
+	TypeBundle     = "bundle"
+	// TypeBasketPercentage and TypeBasketFixed are discounts over the whole basket, applied after the others.
+	TypeBasketPercentage = "basket_percentage"
+	TypeBasketFixed      = "basket_fixed"
+	// TypeBuy2Get1Free is kept for existing configuration files, it is a buy_n_pay_m with buy 2 and pay 1.
+	TypeBuy2Get1Free = "buy_2_get_1_free"
+)
+
+var (
+	// ErrInvalidPromotion is used when a promotion definition can not be built.
+	ErrInvalidPromotion = errors.New("invalid promotion")
+	// ErrPromotionConflict is used when a promotion collides with another one of the configuration.
+	ErrPromotionConflict = errors.New("promotion conflict")
+)
+
+// Config is the promotions configuration file.
+type Config struct {
+	Promotions []Definition `json:"promotions"`
+}
+
+// Definition describes a promotion as written in the configuration file.
+// A bundle lists a code once per unit it takes, ["PEN", "PEN", "MUG"] is two pens and a mug.
+// Promotions are stackable unless "stackable" is false, see Rule for the meaning of the flags.
+// They are always active unless they set starts_at, ends_at or a recurring schedule.
+// Line promotions can target categories instead of or besides products, they apply to every product
+// whose category or tags include one of them, each product priced on its own.
+// Basket discounts have no products, they are sorted by priority and can be capped with max_discount.
+// Disabled promotions are validated but never applied.
+//
+//	{"name": "tshirt-bulk", "type": "buy_x_or_more", "products": ["TSHIRT"], "min_quantity": 3, "discount_percent": 25}
+//	{"name": "drinkware-20", "type": "buy_x_or_more", "categories": ["drinkware"], "min_quantity": 1, "discount_percent": 20}
+//	{"name": "over-50", "type": "basket_percentage", "discount_percent": 10, "min_amount": 50, "max_discount": 20}
+type Definition struct {
+	Name            string              `json:"name"`
+	Type            string              `json:"type"`
+	Products        []string            `json:"products"`
+	Categories      []string            `json:"categories,omitempty"`
+	Buy             int                 `json:"buy,omitempty"`
+	Pay             int                 `json:"pay,omitempty"`
+	MaxApplications int                 `json:"max_applications,omitempty"`
+	MinQuantity     int                 `json:"min_quantity,omitempty"`
+	DiscountPercent float64             `json:"discount_percent,omitempty"`
+	Tiers           []TierDefinition    `json:"tiers,omitempty"`
+	Price           *basket.Money       `json:"price,omitempty"`
+	Amount          *basket.Money       `json:"amount,omitempty"`
+	MinAmount       *basket.Money       `json:"min_amount,omitempty"`
+	MaxDiscount     *basket.Money       `json:"max_discount,omitempty"`
+	Priority        int                 `json:"priority,omitempty"`
+	Exclusive       bool                `json:"exclusive,omitempty"`
+	Stackable       *bool               `json:"stackable,omitempty"`
+	StartsAt        *time.Time          `json:"starts_at,omitempty"`
+	EndsAt          *time.Time          `json:"ends_at,omitempty"`
+	Schedule        *ScheduleDefinition `json:"schedule,omitempty"`
+	Disabled        bool                `json:"disabled,omitempty"`
+}
+
+// TierDefinition is a breakpoint of a tiered promotion, it sets either discount_percent or unit_price.
+type TierDefinition struct {
+	MinQuantity     int           `json:"min_quantity"`
+	DiscountPercent float64       `json:"discount_percent,omitempty"`
+	UnitPrice       *basket.Money `json:"unit_price,omitempty"`
+}
+
+// DefaultConfig returns the promotions requested by the sales department and the CFO.
+func DefaultConfig() Config {
+	return Config{
+		Promotions: []Definition{
+			{Name: "pen-2x1", Type: TypeBuyNPayM, Products: []string{"PEN"}, Buy: 2, Pay: 1},
+			{Name: "tshirt-bulk", Type: TypeBuyXOrMore, Products: []string{"TSHIRT"}, MinQuantity: 3, DiscountPercent: 25},
+		},
+	}
+}
+
+// LoadConfig reads and validates the promotions configuration file.
+func LoadConfig(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %s: %s", ErrInvalidPromotion, path, err.Error())
+	}
+	if _, err := cfg.Build(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Build validates the definitions and returns the promotion set.
+// Several promotions can target the same product, the solver picks the cheapest combination.
+func (c Config) Build() (Set, error) {
+	set := Set{Lines: make(map[string][]Rule)}
+	names := make(map[string]bool)
+	for _, def := range c.Promotions {
+		if names[def.Name] {
+			return Set{}, fmt.Errorf("%w: duplicated name %q", ErrInvalidPromotion, def.Name)
+		}
+		names[def.Name] = true
+
+		schedule, err := def.BuildSchedule()
+		if err != nil {
+			return Set{}, err
+		}
+		if def.Type == TypeBasketPercentage || def.Type == TypeBasketFixed {
+			discount, err := def.BuildBasketDiscount()
+			if err != nil {
+				return Set{}, err
+			}
+			discount.Schedule = schedule
+			if !def.Disabled {
+				set.Discounts = append(set.Discounts, discount)
+			}
+			continue
+		}
+		if def.Type == TypeBundle {
+			bundle, err := def.BuildBundle()
+			if err != nil {
+				return Set{}, err
+			}
+			if !def.Disabled {
+				set.Baskets = append(set.Baskets, def.rule(nil, bundle, schedule))
+			}
+			continue
+		}
+
+		promo, err := def.Build()
+		if err != nil {
+			return Set{}, err
+		}
+		if def.Disabled {
+			continue
+		}
+		for _, code := range def.Products {
+			set.Lines[code] = append(set.Lines[code], def.rule(promo, nil, schedule))
+		}
+		for _, category := range def.Categories {
+			if set.Categories == nil {
+				set.Categories = make(map[string][]Rule)
+			}
+			set.Categories[category] = append(set.Categories[category], def.rule(promo, nil, schedule))
+		}
+	}
+	sortDiscounts(set.Discounts)
+	return set, nil
+}
+
+// Conflict checks def against the promotions of the configuration: names are unique and
+// two enabled promotions of the same type can not target the same product or category.
+func (c Config) Conflict(def Definition) error {
+	for _, other := range c.Promotions {
+		if other.Name == def.Name {
+			return fmt.Errorf("%w: name %q already exists", ErrPromotionConflict, def.Name)
+		}
+		if def.Disabled || other.Disabled || other.Type != def.Type {
+			continue
+		}
+		for _, code := range def.Products {
+			if containsCode(other.Products, code) {
+				return fmt.Errorf("%w: %q and %q are both %s promotions of %s", ErrPromotionConflict, def.Name, other.Name, def.Type, code)
+			}
+		}
+		for _, category := range def.Categories {
+			if containsCode(other.Categories, category) {
+				return fmt.Errorf("%w: %q and %q are both %s promotions of the category %s", ErrPromotionConflict, def.Name, other.Name, def.Type, category)
+			}
+		}
+	}
+	return nil
+}
+
+// Find returns the index of the promotion with the name, or -1 when there is none.
+func (c Config) Find(name string) int {
+	for i, def := range c.Promotions {
+		if def.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsCode(codes []string, code string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (d Definition) rule(line Promotion, bkt BasketPromotion, schedule *Schedule) Rule {
+	return Rule{
+		Name:      d.Name,
+		Exclusive: d.Exclusive,
+		Stackable: d.Stackable == nil || *d.Stackable,
+		Schedule:  schedule,
+		Line:      line,
+		Basket:    bkt,
+	}
+}
+
+// BuildBundle validates a bundle definition and returns its promotion.
+func (d Definition) BuildBundle() (BasketPromotion, error) {
+	if err := d.validateCommon(); err != nil {
+		return nil, err
+	}
+	if d.Type != TypeBundle {
+		return nil, fmt.Errorf("%w: %q: type %q is not a bundle", ErrInvalidPromotion, d.Name, d.Type)
+	}
+	if len(d.Products) == 0 || len(d.Categories) > 0 {
+		return nil, fmt.Errorf("%w: %q: bundles list their products, categories are not allowed", ErrInvalidPromotion, d.Name)
+	}
+	if d.Price == nil || d.Price.Amount < 0 {
+		return nil, fmt.Errorf("%w: %q: price is required and can not be negative", ErrInvalidPromotion, d.Name)
+	}
+	if d.MaxApplications < 0 {
+		return nil, fmt.Errorf("%w: %q: max_applications can not be negative", ErrInvalidPromotion, d.Name)
+	}
+	items := make(map[string]int, len(d.Products))
+	for _, code := range d.Products {
+		items[code]++
+	}
+	return &Bundle{Items: items, Price: *d.Price, MaxApplications: d.MaxApplications}, nil
+}
+
+// BuildBasketDiscount validates a basket discount definition and returns it.
+func (d Definition) BuildBasketDiscount() (BasketDiscount, error) {
+	if d.Name == "" {
+		return BasketDiscount{}, fmt.Errorf("%w: name is required", ErrInvalidPromotion)
+	}
+	if len(d.Products) > 0 || len(d.Categories) > 0 {
+		return BasketDiscount{}, fmt.Errorf("%w: %q: basket discounts apply to the whole basket, products and categories are not allowed", ErrInvalidPromotion, d.Name)
+	}
+	if d.Exclusive || d.Stackable != nil {
+		return BasketDiscount{}, fmt.Errorf("%w: %q: exclusive and stackable do not apply to basket discounts", ErrInvalidPromotion, d.Name)
+	}
+	discount := BasketDiscount{Name: d.Name, Priority: d.Priority}
+	if d.MinAmount != nil {
+		if d.MinAmount.Amount < 0 {
+			return BasketDiscount{}, fmt.Errorf("%w: %q: min_amount can not be negative", ErrInvalidPromotion, d.Name)
+		}
+		discount.MinAmount = *d.MinAmount
+	}
+	if d.MaxDiscount != nil {
+		if d.MaxDiscount.Amount <= 0 {
+			return BasketDiscount{}, fmt.Errorf("%w: %q: max_discount must be greater than zero", ErrInvalidPromotion, d.Name)
+		}
+		maxDiscount := *d.MaxDiscount
+		discount.MaxDiscount = &maxDiscount
+	}
+
+	switch d.Type {
+	case TypeBasketPercentage:
+		percent, err := basket.BasisPoints(d.DiscountPercent)
+		if err != nil {
+			return BasketDiscount{}, fmt.Errorf("%w: %q: %s", ErrInvalidPromotion, d.Name, err.Error())
+		}
+		discount.Percent = percent
+	case TypeBasketFixed:
+		if d.Amount == nil || d.Amount.Amount <= 0 {
+			return BasketDiscount{}, fmt.Errorf("%w: %q: amount must be greater than zero", ErrInvalidPromotion, d.Name)
+		}
+		discount.Amount = *d.Amount
+	default:
+		return BasketDiscount{}, fmt.Errorf("%w: %q: type %q is not a basket discount", ErrInvalidPromotion, d.Name, d.Type)
+	}
+	return discount, nil
+}
+
+// Build validates the definition and returns its line promotion.
+func (d Definition) Build() (Promotion, error) {
+	if err := d.validateCommon(); err != nil {
+		return nil, err
+	}
+
+	switch d.Type {
+	case TypeBuy2Get1Free:
+		d.Buy, d.Pay = 2, 1
+		fallthrough
+	case TypeBuyNPayM:
+		if d.Buy < 2 || d.Pay < 1 || d.Pay >= d.Buy {
+			return nil, fmt.Errorf("%w: %q: buy must be greater than pay and pay greater than zero", ErrInvalidPromotion, d.Name)
+		}
+		if d.MaxApplications < 0 {
+			return nil, fmt.Errorf("%w: %q: max_applications can not be negative", ErrInvalidPromotion, d.Name)
+		}
+		return &BuyNPayM{Buy: d.Buy, Pay: d.Pay, MaxApplications: d.MaxApplications}, nil
+	case TypeBuyXOrMore:
+		if d.MinQuantity <= 0 {
+			return nil, fmt.Errorf("%w: %q: min_quantity must be greater than zero", ErrInvalidPromotion, d.Name)
+		}
+		discount, err := basket.BasisPoints(d.DiscountPercent)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %s", ErrInvalidPromotion, d.Name, err.Error())
+		}
+		return &BuyXOrMore{MinQuantity: d.MinQuantity, Discount: discount}, nil
+	case TypeTiered:
+		tiers, err := buildTiers(d.Tiers)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %s", ErrInvalidPromotion, d.Name, err.Error())
+		}
+		return &Tiered{Tiers: tiers}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q: unknown type %q", ErrInvalidPromotion, d.Name, d.Type)
+	}
+}
+
+func (d Definition) validateCommon() error {
+	if d.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPromotion)
+	}
+	if len(d.Products) == 0 && len(d.Categories) == 0 {
+		return fmt.Errorf("%w: %q: products or categories are required", ErrInvalidPromotion, d.Name)
+	}
+	for _, code := range d.Products {
+		if code == "" {
+			return fmt.Errorf("%w: %q: empty product code", ErrInvalidPromotion, d.Name)
+		}
+	}
+	for _, category := range d.Categories {
+		if category == "" {
+			return fmt.Errorf("%w: %q: empty category", ErrInvalidPromotion, d.Name)
+		}
+	}
+	return nil
+}
+
+func buildTiers(definitions []TierDefinition) ([]Tier, error) {
+	if len(definitions) == 0 {
+		return nil, errors.New("tiers are required")
+	}
+	tiers := make([]Tier, 0, len(definitions))
+	for i, def := range definitions {
+		if def.MinQuantity <= 0 {
+			return nil, errors.New("tier min_quantity must be greater than zero")
+		}
+		if i > 0 && def.MinQuantity <= definitions[i-1].MinQuantity {
+			return nil, errors.New("tiers must be sorted by increasing min_quantity")
+		}
+		if (def.DiscountPercent != 0) == (def.UnitPrice != nil) {
+			return nil, errors.New("each tier sets either discount_percent or unit_price")
+		}
+		if def.UnitPrice != nil {
+			if def.UnitPrice.Amount < 0 {
+				return nil, errors.New("tier unit_price can not be negative")
+			}
+			price := *def.UnitPrice
+			tiers = append(tiers, Tier{MinQuantity: def.MinQuantity, UnitPrice: &price})
+			continue
+		}
+		discount, err := basket.BasisPoints(def.DiscountPercent)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, Tier{MinQuantity: def.MinQuantity, Discount: discount})
+	}
+	return tiers, nil
+}
@@ -0,0 +1,299 @@
+package promotion
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigBuild(t *testing.T) {
+	tierUnitPrice := basket.NewMoney(500, "")
+	notStackable := false
+	tests := []struct {
+		name          string
+		config        Config
+		expected      Set
+		expectedError bool
+	}{
+		{
+			name:   "Build - Ok - Default config",
+			config: DefaultConfig(),
+			expected: Set{Lines: map[string][]Rule{
+				"PEN":    {{Name: "pen-2x1", Stackable: true, Line: &BuyNPayM{Buy: 2, Pay: 1}}},
+				"TSHIRT": {{Name: "tshirt-bulk", Stackable: true, Line: &BuyXOrMore{MinQuantity: 3, Discount: 2500}}},
+			}},
+		},
+		{
+			name: "Build - Ok - Fractional percent",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs", Type: TypeBuyXOrMore, Products: []string{"MUG"}, MinQuantity: 5, DiscountPercent: 12.5},
+			}},
+			expected: Set{Lines: map[string][]Rule{
+				"MUG": {{Name: "mugs", Stackable: true, Line: &BuyXOrMore{MinQuantity: 5, Discount: 1250}}},
+			}},
+		},
+		{
+			name: "Build - Ok - Legacy buy 2 get 1 free",
+			config: Config{Promotions: []Definition{
+				{Name: "pens", Type: TypeBuy2Get1Free, Products: []string{"PEN"}},
+			}},
+			expected: Set{Lines: map[string][]Rule{
+				"PEN": {{Name: "pens", Stackable: true, Line: &BuyNPayM{Buy: 2, Pay: 1}}},
+			}},
+		},
+		{
+			name: "Build - Ok - Buy 3 pay 2 limited",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs-3x2", Type: TypeBuyNPayM, Products: []string{"MUG"}, Buy: 3, Pay: 2, MaxApplications: 1},
+			}},
+			expected: Set{Lines: map[string][]Rule{
+				"MUG": {{Name: "mugs-3x2", Stackable: true, Line: &BuyNPayM{Buy: 3, Pay: 2, MaxApplications: 1}}},
+			}},
+		},
+		{
+			name: "Build - Error - Pay not lower than buy",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs", Type: TypeBuyNPayM, Products: []string{"MUG"}, Buy: 2, Pay: 2},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Ok - Tiered",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs-volume", Type: TypeTiered, Products: []string{"MUG"}, Tiers: []TierDefinition{
+					{MinQuantity: 3, DiscountPercent: 10},
+					{MinQuantity: 10, UnitPrice: &tierUnitPrice},
+				}},
+			}},
+			expected: Set{Lines: map[string][]Rule{
+				"MUG": {{Name: "mugs-volume", Stackable: true, Line: &Tiered{Tiers: []Tier{{MinQuantity: 3, Discount: 1000}, {MinQuantity: 10, UnitPrice: &tierUnitPrice}}}}},
+			}},
+		},
+		{
+			name: "Build - Ok - Bundle with line promotion on the same product",
+			config: Config{Promotions: []Definition{
+				{Name: "lana-pack", Type: TypeBundle, Products: []string{"PEN", "PEN", "MUG"}, Price: &tierUnitPrice},
+				{Name: "pen-2x1", Type: TypeBuyNPayM, Products: []string{"PEN"}, Buy: 2, Pay: 1},
+			}},
+			expected: Set{
+				Lines: map[string][]Rule{"PEN": {{Name: "pen-2x1", Stackable: true, Line: &BuyNPayM{Buy: 2, Pay: 1}}}},
+				Baskets: []Rule{{
+					Name:      "lana-pack",
+					Stackable: true,
+					Basket:    &Bundle{Items: map[string]int{"PEN": 2, "MUG": 1}, Price: tierUnitPrice},
+				}},
+			},
+		},
+		{
+			name: "Build - Error - Bundle without price",
+			config: Config{Promotions: []Definition{
+				{Name: "lana-pack", Type: TypeBundle, Products: []string{"PEN", "MUG"}},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Error - Tiers not sorted",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs-volume", Type: TypeTiered, Products: []string{"MUG"}, Tiers: []TierDefinition{
+					{MinQuantity: 10, DiscountPercent: 25},
+					{MinQuantity: 3, DiscountPercent: 10},
+				}},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Error - Tier with discount and unit price",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs-volume", Type: TypeTiered, Products: []string{"MUG"}, Tiers: []TierDefinition{
+					{MinQuantity: 3, DiscountPercent: 10, UnitPrice: &tierUnitPrice},
+				}},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Error - Unknown type",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs", Type: "random", Products: []string{"MUG"}},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Error - Missing products",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs", Type: TypeBuyNPayM, Buy: 2, Pay: 1},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Error - Invalid percent",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs", Type: TypeBuyXOrMore, Products: []string{"MUG"}, MinQuantity: 5, DiscountPercent: 120},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Ok - Competing promotions with flags",
+			config: Config{Promotions: []Definition{
+				{Name: "mugs-2x1", Type: TypeBuyNPayM, Products: []string{"MUG"}, Buy: 2, Pay: 1, Exclusive: true},
+				{Name: "mugs-bulk", Type: TypeBuyXOrMore, Products: []string{"MUG"}, MinQuantity: 5, DiscountPercent: 10, Stackable: &notStackable},
+			}},
+			expected: Set{Lines: map[string][]Rule{
+				"MUG": {
+					{Name: "mugs-2x1", Exclusive: true, Stackable: true, Line: &BuyNPayM{Buy: 2, Pay: 1}},
+					{Name: "mugs-bulk", Line: &BuyXOrMore{MinQuantity: 5, Discount: 1000}},
+				},
+			}},
+		},
+		{
+			name: "Build - Ok - Basket discounts sorted by priority",
+			config: Config{Promotions: []Definition{
+				{Name: "over-50", Type: TypeBasketPercentage, DiscountPercent: 10, MinAmount: &tierUnitPrice, MaxDiscount: &tierUnitPrice, Priority: 2},
+				{Name: "five-off", Type: TypeBasketFixed, Amount: &tierUnitPrice, Priority: 1},
+			}},
+			expected: Set{
+				Lines: map[string][]Rule{},
+				Discounts: []BasketDiscount{
+					{Name: "five-off", Priority: 1, Amount: tierUnitPrice},
+					{Name: "over-50", Priority: 2, MinAmount: tierUnitPrice, Percent: 1000, MaxDiscount: &tierUnitPrice},
+				},
+			},
+		},
+		{
+			name: "Build - Error - Basket discount with products",
+			config: Config{Promotions: []Definition{
+				{Name: "over-50", Type: TypeBasketPercentage, Products: []string{"MUG"}, DiscountPercent: 10},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Error - Fixed basket discount without amount",
+			config: Config{Promotions: []Definition{
+				{Name: "five-off", Type: TypeBasketFixed},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Ok - Categories",
+			config: Config{Promotions: []Definition{
+				{Name: "drinkware-20", Type: TypeBuyXOrMore, Categories: []string{"drinkware"}, MinQuantity: 1, DiscountPercent: 20},
+			}},
+			expected: Set{
+				Lines: map[string][]Rule{},
+				Categories: map[string][]Rule{
+					"drinkware": {{Name: "drinkware-20", Stackable: true, Line: &BuyXOrMore{MinQuantity: 1, Discount: 2000}}},
+				},
+			},
+		},
+		{
+			name: "Build - Error - Bundle with categories",
+			config: Config{Promotions: []Definition{
+				{Name: "lana-pack", Type: TypeBundle, Products: []string{"PEN", "MUG"}, Categories: []string{"drinkware"}, Price: &tierUnitPrice},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Error - Basket discount with categories",
+			config: Config{Promotions: []Definition{
+				{Name: "over-50", Type: TypeBasketPercentage, Categories: []string{"drinkware"}, DiscountPercent: 10},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Error - Empty category",
+			config: Config{Promotions: []Definition{
+				{Name: "drinkware-20", Type: TypeBuyXOrMore, Categories: []string{""}, MinQuantity: 1, DiscountPercent: 20},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "Build - Error - Duplicated name",
+			config: Config{Promotions: []Definition{
+				{Name: "promo", Type: TypeBuyNPayM, Products: []string{"MUG"}, Buy: 2, Pay: 1},
+				{Name: "promo", Type: TypeBuyNPayM, Products: []string{"PEN"}, Buy: 2, Pay: 1},
+			}},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promotions, err := tt.config.Build()
+			if tt.expectedError {
+				require.True(t, errors.Is(err, ErrInvalidPromotion))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, promotions)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "promotions.json")
+	err := ioutil.WriteFile(path, []byte(`{"promotions": [
+		{"name": "mugs", "type": "buy_x_or_more", "products": ["MUG"], "min_quantity": 5, "discount_percent": 10}
+	]}`), 0600)
+	require.NoError(t, err)
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, []Definition{
+		{Name: "mugs", Type: TypeBuyXOrMore, Products: []string{"MUG"}, MinQuantity: 5, DiscountPercent: 10},
+	}, cfg.Promotions)
+
+	err = ioutil.WriteFile(path, []byte(`{"promotions": [{"name": "mugs", "type": "buy_x_or_more"}]}`), 0600)
+	require.NoError(t, err)
+	_, err = LoadConfig(path)
+	require.True(t, errors.Is(err, ErrInvalidPromotion))
+
+	_, err = LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestLoadConfigRepositoryFile(t *testing.T) {
+	cfg, err := LoadConfig("../../../config/promotions.json")
+	require.NoError(t, err)
+	require.Equal(t, DefaultConfig(), cfg)
+}
+
+func TestConfigConflict(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Promotions = append(cfg.Promotions, Definition{Name: "office-2x1", Type: TypeBuyNPayM, Categories: []string{"office"}, Buy: 2, Pay: 1})
+	tests := []struct {
+		name        string
+		definition  Definition
+		expectedErr error
+	}{
+		{name: "Conflict - None", definition: Definition{Name: "mug-2x1", Type: TypeBuyNPayM, Products: []string{"MUG"}}},
+		{name: "Conflict - Other type on the same product", definition: Definition{Name: "pen-bulk", Type: TypeBuyXOrMore, Products: []string{"PEN"}}},
+		{name: "Conflict - Same type disabled", definition: Definition{Name: "pen-3x2", Type: TypeBuyNPayM, Products: []string{"PEN"}, Disabled: true}},
+		{name: "Conflict - Duplicated name", definition: Definition{Name: "pen-2x1", Type: TypeTiered, Products: []string{"MUG"}}, expectedErr: ErrPromotionConflict},
+		{name: "Conflict - Other category", definition: Definition{Name: "drinkware-3x2", Type: TypeBuyNPayM, Categories: []string{"drinkware"}}},
+		{name: "Conflict - Same type on the same category", definition: Definition{Name: "office-3x2", Type: TypeBuyNPayM, Categories: []string{"office"}}, expectedErr: ErrPromotionConflict},
+		{name: "Conflict - Same type on the same product", definition: Definition{Name: "pen-3x2", Type: TypeBuyNPayM, Products: []string{"MUG", "PEN"}}, expectedErr: ErrPromotionConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cfg.Conflict(tt.definition)
+			require.True(t, errors.Is(err, tt.expectedErr))
+		})
+	}
+}
+
+func TestConfigBuildDisabled(t *testing.T) {
+	set, err := Config{Promotions: []Definition{
+		{Name: "pen-2x1", Type: TypeBuyNPayM, Products: []string{"PEN"}, Buy: 2, Pay: 1, Disabled: true},
+	}}.Build()
+	require.NoError(t, err)
+	require.Empty(t, set.Lines)
+
+	_, err = Config{Promotions: []Definition{
+		{Name: "pen-2x1", Type: TypeBuyNPayM, Products: []string{"PEN"}, Buy: 2, Pay: 2, Disabled: true},
+	}}.Build()
+	require.True(t, errors.Is(err, ErrInvalidPromotion))
+}
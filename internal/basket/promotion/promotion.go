@@ -0,0 +1,48 @@
+package promotion
+
+import "github.com/mercadolibre/backend-challenge/internal/basket"
+
+// Promotion interface is used to manage the Promotion methods.
+type Promotion interface {
+	Compute(product basket.Product, quantity int) basket.Money
+}
+
+// BasketPromotion interface is used to manage the promotions that need the whole basket, like bundles.
+type BasketPromotion interface {
+	// Apply returns the price of the units the promotion takes from products and the units taken.
+	// The promotion is applied at most limit times.
+	Apply(products map[string]int, catalog map[string]basket.Product, limit int) (basket.Money, map[string]int)
+}
+
+// SteppedPromotion is implemented by the line promotions whose price follows the same pattern every period units
+// once past their breakpoints, so the solver does not need to evaluate every number of units a bundle can leave.
+type SteppedPromotion interface {
+	// Steps returns the quantities where the pattern changes and the number of units it repeats every.
+	Steps() (breakpoints []int, period int)
+}
+
+// Rule is a built promotion with the flags the solver needs to combine it with the others.
+// Exactly one of Line or Basket is set.
+type Rule struct {
+	Name string
+	// Exclusive rules are never combined with another promotion in the same basket.
+	Exclusive bool
+	// Stackable rules can be combined with other promotions on the same product,
+	// e.g. a line promotion pricing the units a bundle did not take.
+	Stackable bool
+	// Schedule restricts when the rule can be applied, see Set.Active. It is nil for rules always active.
+	Schedule *Schedule
+	Line     Promotion
+	Basket   BasketPromotion
+}
+
+// Set is a validated group of promotions ready to price a basket.
+type Set struct {
+	// Lines keeps the line rules that compete for each product code.
+	Lines map[string][]Rule
+	// Categories keeps the line rules of the products of each category or tag.
+	Categories map[string][]Rule
+	Baskets    []Rule
+	// Discounts are applied after the line and basket rules, sorted by Priority.
+	Discounts []BasketDiscount
+}
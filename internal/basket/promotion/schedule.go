@@ -0,0 +1,164 @@
+package promotion
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// ScheduleDefinition describes the recurring window of a promotion as written in the configuration file.
+// Hours are "HH:MM" in the timezone, UTC when it is empty. A window ending before it starts crosses midnight
+// and belongs to the weekday it starts on.
+//
+//	{"weekdays": ["friday"], "from": "17:00", "to": "19:00", "timezone": "Europe/Madrid"}
+type ScheduleDefinition struct {
+	Weekdays []string `json:"weekdays,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       string   `json:"to,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+// Schedule is the validated time window of a promotion. A nil Schedule is always active.
+type Schedule struct {
+	Starts *time.Time
+	Ends   *time.Time
+	// Weekdays the promotion is active, every day when empty.
+	Weekdays map[time.Weekday]bool
+	// From and To are the offsets since midnight of the daily window, the whole day when both are zero.
+	From     time.Duration
+	To       time.Duration
+	Location *time.Location
+}
+
+// Active reports whether the promotion can be applied at now.
+func (s *Schedule) Active(now time.Time) bool {
+	if s == nil {
+		return true
+	}
+	if s.Starts != nil && now.Before(*s.Starts) {
+		return false
+	}
+	if s.Ends != nil && !now.Before(*s.Ends) {
+		return false
+	}
+
+	local := now.In(s.Location)
+	day := local.Weekday()
+	if s.From != s.To {
+		clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+			time.Duration(local.Second())*time.Second
+		switch {
+		case s.From < s.To:
+			if clock < s.From || clock >= s.To {
+				return false
+			}
+		case clock < s.To:
+			// After midnight of a window started the day before.
+			day = (day + 6) % 7
+		case clock < s.From:
+			return false
+		}
+	}
+	return len(s.Weekdays) == 0 || s.Weekdays[day]
+}
+
+// BuildSchedule validates the time window of the definition, it returns nil when the promotion is always active.
+func (d Definition) BuildSchedule() (*Schedule, error) {
+	if d.StartsAt == nil && d.EndsAt == nil && d.Schedule == nil {
+		return nil, nil
+	}
+	if d.StartsAt != nil && d.EndsAt != nil && !d.EndsAt.After(*d.StartsAt) {
+		return nil, fmt.Errorf("%w: %q: ends_at must be after starts_at", ErrInvalidPromotion, d.Name)
+	}
+	schedule := &Schedule{Starts: d.StartsAt, Ends: d.EndsAt, Location: time.UTC}
+	if d.Schedule == nil {
+		return schedule, nil
+	}
+
+	if d.Schedule.Timezone != "" {
+		location, err := time.LoadLocation(d.Schedule.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: unknown timezone %q", ErrInvalidPromotion, d.Name, d.Schedule.Timezone)
+		}
+		schedule.Location = location
+	}
+	if len(d.Schedule.Weekdays) > 0 {
+		schedule.Weekdays = make(map[time.Weekday]bool, len(d.Schedule.Weekdays))
+		for _, name := range d.Schedule.Weekdays {
+			day, exists := weekdays[strings.ToLower(name)]
+			if !exists {
+				return nil, fmt.Errorf("%w: %q: unknown weekday %q", ErrInvalidPromotion, d.Name, name)
+			}
+			schedule.Weekdays[day] = true
+		}
+	}
+	if (d.Schedule.From == "") != (d.Schedule.To == "") {
+		return nil, fmt.Errorf("%w: %q: schedule from and to are set together", ErrInvalidPromotion, d.Name)
+	}
+	if d.Schedule.From != "" {
+		var err error
+		if schedule.From, err = clockOffset(d.Schedule.From); err != nil {
+			return nil, fmt.Errorf("%w: %q: schedule from: %s", ErrInvalidPromotion, d.Name, err.Error())
+		}
+		if schedule.To, err = clockOffset(d.Schedule.To); err != nil {
+			return nil, fmt.Errorf("%w: %q: schedule to: %s", ErrInvalidPromotion, d.Name, err.Error())
+		}
+		if schedule.From == schedule.To {
+			return nil, fmt.Errorf("%w: %q: schedule from and to can not be equal", ErrInvalidPromotion, d.Name)
+		}
+	}
+	return schedule, nil
+}
+
+// clockOffset converts "HH:MM" to the offset since midnight.
+func clockOffset(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("hours must be written as HH:MM")
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// Active returns the rules of the set that can be applied at now.
+func (s Set) Active(now time.Time) Set {
+	active := Set{Lines: make(map[string][]Rule, len(s.Lines))}
+	for code, rules := range s.Lines {
+		for _, rule := range rules {
+			if rule.Schedule.Active(now) {
+				active.Lines[code] = append(active.Lines[code], rule)
+			}
+		}
+	}
+	for category, rules := range s.Categories {
+		for _, rule := range rules {
+			if rule.Schedule.Active(now) {
+				if active.Categories == nil {
+					active.Categories = make(map[string][]Rule)
+				}
+				active.Categories[category] = append(active.Categories[category], rule)
+			}
+		}
+	}
+	for _, rule := range s.Baskets {
+		if rule.Schedule.Active(now) {
+			active.Baskets = append(active.Baskets, rule)
+		}
+	}
+	for _, discount := range s.Discounts {
+		if discount.Schedule.Active(now) {
+			active.Discounts = append(active.Discounts, discount)
+		}
+	}
+	return active
+}
@@ -0,0 +1,114 @@
+package promotion
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleActive(t *testing.T) {
+	// 2024-03-01 is a Friday.
+	friday := func(hour, minute int) time.Time { return time.Date(2024, 3, 1, hour, minute, 0, 0, time.UTC) }
+	starts := friday(0, 0)
+	ends := friday(0, 0).AddDate(0, 0, 7)
+
+	tests := []struct {
+		name       string
+		definition Definition
+		now        time.Time
+		expected   bool
+	}{
+		{name: "Active - Always", definition: Definition{}, now: friday(12, 0), expected: true},
+		{name: "Active - Before starts_at", definition: Definition{StartsAt: &starts}, now: starts.Add(-time.Second), expected: false},
+		{name: "Active - At starts_at", definition: Definition{StartsAt: &starts}, now: starts, expected: true},
+		{name: "Active - At ends_at", definition: Definition{StartsAt: &starts, EndsAt: &ends}, now: ends, expected: false},
+		{
+			name:       "Active - Happy hour",
+			definition: Definition{Schedule: &ScheduleDefinition{Weekdays: []string{"Friday"}, From: "17:00", To: "19:00"}},
+			now:        friday(17, 30),
+			expected:   true,
+		},
+		{
+			name:       "Active - Happy hour ended",
+			definition: Definition{Schedule: &ScheduleDefinition{Weekdays: []string{"friday"}, From: "17:00", To: "19:00"}},
+			now:        friday(19, 0),
+			expected:   false,
+		},
+		{
+			name:       "Active - Happy hour other weekday",
+			definition: Definition{Schedule: &ScheduleDefinition{Weekdays: []string{"monday"}, From: "17:00", To: "19:00"}},
+			now:        friday(17, 30),
+			expected:   false,
+		},
+		{
+			name:       "Active - Happy hour in the timezone",
+			definition: Definition{Schedule: &ScheduleDefinition{From: "17:00", To: "19:00", Timezone: "Europe/Madrid"}},
+			now:        friday(16, 30),
+			expected:   true,
+		},
+		{
+			name:       "Active - Overnight window belongs to the weekday it starts",
+			definition: Definition{Schedule: &ScheduleDefinition{Weekdays: []string{"thursday"}, From: "22:00", To: "02:00"}},
+			now:        friday(1, 0),
+			expected:   true,
+		},
+		{
+			name:       "Active - Overnight window after it ends",
+			definition: Definition{Schedule: &ScheduleDefinition{Weekdays: []string{"friday"}, From: "22:00", To: "02:00"}},
+			now:        friday(1, 0),
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := tt.definition.BuildSchedule()
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, schedule.Active(tt.now))
+		})
+	}
+}
+
+func TestBuildScheduleErrors(t *testing.T) {
+	starts := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	ends := starts.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		definition Definition
+	}{
+		{name: "Schedule - Ends before starts", definition: Definition{StartsAt: &starts, EndsAt: &ends}},
+		{name: "Schedule - Unknown weekday", definition: Definition{Schedule: &ScheduleDefinition{Weekdays: []string{"someday"}}}},
+		{name: "Schedule - Unknown timezone", definition: Definition{Schedule: &ScheduleDefinition{Timezone: "Mars/Olympus"}}},
+		{name: "Schedule - From without to", definition: Definition{Schedule: &ScheduleDefinition{From: "17:00"}}},
+		{name: "Schedule - Invalid hour", definition: Definition{Schedule: &ScheduleDefinition{From: "25:00", To: "19:00"}}},
+		{name: "Schedule - Empty window", definition: Definition{Schedule: &ScheduleDefinition{From: "17:00", To: "17:00"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.definition.BuildSchedule()
+			require.True(t, errors.Is(err, ErrInvalidPromotion))
+		})
+	}
+}
+
+func TestSetActive(t *testing.T) {
+	set, err := Config{Promotions: append([]Definition{
+		{
+			Name: "mug-happy-hour", Type: TypeBuyXOrMore, Products: []string{"MUG"}, MinQuantity: 1, DiscountPercent: 50,
+			Schedule: &ScheduleDefinition{From: "17:00", To: "19:00"},
+		},
+	}, DefaultConfig().Promotions...)}.Build()
+	require.NoError(t, err)
+
+	active := set.Active(time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC))
+	require.Len(t, active.Lines["MUG"], 1)
+	require.Len(t, active.Lines["PEN"], 1)
+
+	inactive := set.Active(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	require.Empty(t, inactive.Lines["MUG"])
+	require.Len(t, inactive.Lines["PEN"], 1)
+}
@@ -0,0 +1,386 @@
+package promotion
+
+import (
+	"sort"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+)
+
+const (
+	// maxSolverNodes bounds the bundle combinations explored for a single basket.
+	// Past it the solver keeps the best combination found so far.
+	maxSolverNodes = 10000
+	// maxBundleCounts bounds the numbers of applications of a bundle evaluated one by one.
+	maxBundleCounts = 64
+	unlimited       = int(^uint(0) >> 1)
+)
+
+// Result is the cheapest combination of promotions found for a basket, with its price breakdown.
+type Result struct {
+	Total   basket.Money
+	Applied []basket.AppliedPromotion
+	Lines   []basket.LineItem
+}
+
+// Solve returns the cheapest valid combination of promotions for the products.
+//
+// Bundles compete with the line promotions for the same units, so the numbers of applications
+// of each bundle that can be the cheapest are evaluated, see bundleCounts, and the units left go to
+// the cheapest line promotion of their product.
+// The line promotions of a parent product price the units of all its variants together.
+// Exclusive rules are evaluated alone, with the rest of the basket at list price.
+func (s Set) Solve(products map[string]int, catalog map[string]basket.Product, currency string) Result {
+	solver := solver{set: s, catalog: catalog, currency: currency}
+
+	var shared []Rule
+	for _, rule := range s.Baskets {
+		if !rule.Exclusive {
+			shared = append(shared, rule)
+		}
+	}
+	best := solver.combine(shared, copyUnits(products), nil)
+
+	for _, rule := range s.Baskets {
+		if rule.Exclusive {
+			best = cheapest(best, solver.exclusiveBasket(rule, products))
+		}
+	}
+	lineCodes := make([]string, 0, len(s.Lines))
+	for code := range s.Lines {
+		lineCodes = append(lineCodes, code)
+	}
+	sort.Strings(lineCodes)
+	categories := make([]string, 0, len(s.Categories))
+	for category := range s.Categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	evaluated := make(map[string]bool)
+	for _, code := range lineCodes {
+		for _, rule := range s.Lines[code] {
+			if rule.Exclusive && !evaluated[rule.Name] {
+				evaluated[rule.Name] = true
+				best = cheapest(best, solver.exclusiveLine(rule, products))
+			}
+		}
+	}
+	for _, category := range categories {
+		for _, rule := range s.Categories[category] {
+			if rule.Exclusive && !evaluated[rule.Name] {
+				evaluated[rule.Name] = true
+				best = cheapest(best, solver.exclusiveLine(rule, products))
+			}
+		}
+	}
+	best.Lines = lineItems(products, catalog, currency, best.Applied)
+	return best
+}
+
+type solver struct {
+	set      Set
+	catalog  map[string]basket.Product
+	currency string
+	nodes    int
+}
+
+// combine explores the applications of the first basket rule and recurses on the others.
+func (s *solver) combine(rules []Rule, remaining map[string]int, applied []bundleApplication) Result {
+	s.nodes++
+	if len(rules) == 0 || s.nodes > maxSolverNodes {
+		return s.priceLines(remaining, applied)
+	}
+
+	rule := rules[0]
+	var options []bundleApplication
+	for _, limit := range s.bundleCounts(rule, rules[1:], remaining) {
+		price, consumed := rule.Basket.Apply(remaining, s.catalog, limit)
+		options = append(options, bundleApplication{rule: rule, price: price, consumed: consumed})
+	}
+
+	best := s.combine(rules[1:], remaining, applied)
+	for _, option := range options {
+		left := copyUnits(remaining)
+		for code, units := range option.consumed {
+			left[code] -= units
+		}
+		best = cheapest(best, s.combine(rules[1:], left, append(applied[:len(applied):len(applied)], option)))
+	}
+	return best
+}
+
+// bundleCounts returns the numbers of applications of the basket rule worth evaluating, from one to the most
+// it can be applied. Every number is evaluated when they are a few or when other basket rules take units of the same
+// products. Otherwise the line promotions of the units the rule takes follow the same pattern every period units
+// past their breakpoints, so the cheapest number is next to the ends or to the breakpoints and only those are evaluated.
+func (s *solver) bundleCounts(rule Rule, others []Rule, remaining map[string]int) []int {
+	_, once := rule.Basket.Apply(remaining, s.catalog, 1)
+	if len(once) == 0 {
+		return nil
+	}
+	_, all := rule.Basket.Apply(remaining, s.catalog, unlimited)
+	code := sortedCodes(once)[0]
+	most := all[code] / once[code]
+	if most <= maxBundleCounts {
+		return countsBetween(1, most, most)
+	}
+	for _, other := range others {
+		_, shared := other.Basket.Apply(remaining, s.catalog, 1)
+		for code := range shared {
+			if once[code] > 0 {
+				return countsBetween(1, most, most)
+			}
+		}
+	}
+
+	// The units of a product and of its variants are priced together, under the code of the parent.
+	groups := lineGroups(remaining, s.catalog)
+	taken := make(map[string]int)
+	for code, units := range once {
+		group := code
+		if parent := s.catalog[code].Parent; parent != "" {
+			group = parent
+		}
+		taken[group] += units
+	}
+	period := 1
+	var breakpoints []int
+	for _, group := range sortedCodes(taken) {
+		quantity := 0
+		for _, code := range groups[group] {
+			quantity += remaining[code]
+		}
+		for _, line := range s.set.lineRules(group, s.catalog[group]) {
+			stepped, ok := line.Line.(SteppedPromotion)
+			if !ok {
+				return countsBetween(1, most, most)
+			}
+			quantities, every := stepped.Steps()
+			if period = lcm(period, every/gcd(every, taken[group])); period > maxBundleCounts {
+				period = maxBundleCounts
+			}
+			for _, q := range quantities {
+				// The number of applications that leaves q units of the group.
+				breakpoints = append(breakpoints, (quantity-q)/taken[group])
+			}
+		}
+	}
+
+	counts := countsBetween(1, period, most)
+	counts = append(counts, countsBetween(most-period, most, most)...)
+	for _, count := range breakpoints {
+		counts = append(counts, countsBetween(count-period, count+period, most)...)
+	}
+	sort.Ints(counts)
+	unique := counts[:0]
+	for i, count := range counts {
+		if i == 0 || count != counts[i-1] {
+			unique = append(unique, count)
+		}
+	}
+	return unique
+}
+
+// countsBetween returns the numbers from first to last, both included, between one and most.
+func countsBetween(first, last, most int) []int {
+	if first < 1 {
+		first = 1
+	}
+	if last > most {
+		last = most
+	}
+	var counts []int
+	for count := first; count <= last; count++ {
+		counts = append(counts, count)
+	}
+	return counts
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func lcm(a, b int) int {
+	return a / gcd(a, b) * b
+}
+
+// priceLines prices the units left after the bundles with the cheapest allowed line promotion.
+func (s *solver) priceLines(remaining map[string]int, applied []bundleApplication) Result {
+	result := Result{Total: basket.NewMoney(0, s.currency)}
+	touchedBy := make(map[string][]Rule)
+	for _, application := range applied {
+		result.Total = result.Total.Add(application.price)
+		result.Applied = append(result.Applied, basket.AppliedPromotion{
+			Name:     application.rule.Name,
+			Products: application.consumed,
+			Discount: listPrice(application.consumed, s.catalog, s.currency).Sub(application.price),
+		})
+		for code := range application.consumed {
+			touchedBy[code] = append(touchedBy[code], application.rule)
+		}
+	}
+
+	groups := lineGroups(remaining, s.catalog)
+	for _, group := range sortedGroups(groups) {
+		lines, units := s.units(groups[group], remaining)
+		gross := listPrice(units, s.catalog, s.currency)
+		var touched []Rule
+		for _, code := range groups[group] {
+			touched = append(touched, touchedBy[code]...)
+		}
+		price, chosen := gross, ""
+		for _, rule := range s.set.lineRules(group, s.catalog[group]) {
+			if rule.Exclusive || !stacks(rule, touched) {
+				continue
+			}
+			if amount := computeLines(rule.Line, lines); amount.Amount < price.Amount {
+				price, chosen = amount, rule.Name
+			}
+		}
+		result.Total = result.Total.Add(price)
+		if chosen != "" {
+			result.Applied = append(result.Applied, basket.AppliedPromotion{
+				Name:     chosen,
+				Products: units,
+				Discount: gross.Sub(price),
+			})
+		}
+	}
+	return result
+}
+
+func (s *solver) exclusiveBasket(rule Rule, products map[string]int) Result {
+	price, consumed := rule.Basket.Apply(products, s.catalog, unlimited)
+	if len(consumed) == 0 {
+		return s.listPriceResult(products)
+	}
+	remaining := copyUnits(products)
+	for code, units := range consumed {
+		remaining[code] -= units
+	}
+	result := s.listPriceResult(remaining)
+	result.Total = result.Total.Add(price)
+	result.Applied = []basket.AppliedPromotion{{
+		Name:     rule.Name,
+		Products: consumed,
+		Discount: listPrice(consumed, s.catalog, s.currency).Sub(price),
+	}}
+	return result
+}
+
+func (s *solver) exclusiveLine(rule Rule, products map[string]int) Result {
+	result := s.listPriceResult(products)
+	groups := lineGroups(products, s.catalog)
+	for _, group := range sortedGroups(groups) {
+		if !containsRule(s.set.lineRules(group, s.catalog[group]), rule.Name) {
+			continue
+		}
+		lines, units := s.units(groups[group], products)
+		discount := listPrice(units, s.catalog, s.currency).Sub(computeLines(rule.Line, lines))
+		if discount.Amount <= 0 {
+			continue
+		}
+		result.Total = result.Total.Sub(discount)
+		result.Applied = append(result.Applied, basket.AppliedPromotion{
+			Name:     rule.Name,
+			Products: units,
+			Discount: discount,
+		})
+	}
+	return result
+}
+
+// units returns the lines of the codes and their units.
+func (s *solver) units(codes []string, products map[string]int) ([]Units, map[string]int) {
+	lines := make([]Units, 0, len(codes))
+	units := make(map[string]int, len(codes))
+	for _, code := range codes {
+		lines = append(lines, Units{Product: s.catalog[code], Quantity: products[code]})
+		units[code] = products[code]
+	}
+	return lines, units
+}
+
+// lineRules returns the line rules of the product, the ones of its code and of its category and tags.
+func (s Set) lineRules(code string, product basket.Product) []Rule {
+	rules := s.Lines[code]
+	if len(s.Categories) == 0 {
+		return rules
+	}
+	rules = append([]Rule{}, rules...)
+	for _, category := range append([]string{product.Category}, product.Tags...) {
+		for _, rule := range s.Categories[category] {
+			if !containsRule(rules, rule.Name) {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+func (s *solver) listPriceResult(products map[string]int) Result {
+	return Result{Total: listPrice(products, s.catalog, s.currency)}
+}
+
+type bundleApplication struct {
+	rule     Rule
+	price    basket.Money
+	consumed map[string]int
+}
+
+// stacks reports whether the line rule can price the units left by the basket rules.
+func stacks(line Rule, basketRules []Rule) bool {
+	for _, rule := range basketRules {
+		if !line.Stackable || !rule.Stackable {
+			return false
+		}
+	}
+	return true
+}
+
+// cheapest keeps the current result on ties, so the first combination explored wins.
+func cheapest(current, candidate Result) Result {
+	if candidate.Total.Amount < current.Total.Amount {
+		return candidate
+	}
+	return current
+}
+
+func listPrice(products map[string]int, catalog map[string]basket.Product, currency string) basket.Money {
+	withoutPromo := WithoutPromo{}
+	total := basket.NewMoney(0, currency)
+	for code, quantity := range products {
+		if quantity > 0 {
+			total = total.Add(withoutPromo.Compute(catalog[code], quantity))
+		}
+	}
+	return total
+}
+
+func containsRule(rules []Rule, name string) bool {
+	for _, rule := range rules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func copyUnits(products map[string]int) map[string]int {
+	units := make(map[string]int, len(products))
+	for code, quantity := range products {
+		units[code] = quantity
+	}
+	return units
+}
+
+func sortedCodes(products map[string]int) []string {
+	codes := make([]string, 0, len(products))
+	for code := range products {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,233 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSolve(t *testing.T) {
+	catalog := map[string]basket.Product{
+		"PEN":    {Code: "PEN", Price: basket.NewMoney(500, basket.DefaultCurrency)},
+		"TSHIRT": {Code: "TSHIRT", Price: basket.NewMoney(2000, basket.DefaultCurrency)},
+		"MUG":    {Code: "MUG", Price: basket.NewMoney(750, basket.DefaultCurrency)},
+	}
+	price := func(amount int64) *basket.Money {
+		money := basket.NewMoney(amount, "")
+		return &money
+	}
+	notStackable := false
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+
+	tests := []struct {
+		name            string
+		definitions     []Definition
+		products        map[string]int
+		expectedTotal   int64
+		expectedApplied []basket.AppliedPromotion
+	}{
+		{
+			name:          "Solve - Code challenge example",
+			definitions:   DefaultConfig().Promotions,
+			products:      map[string]int{"PEN": 3, "TSHIRT": 3, "MUG": 1},
+			expectedTotal: 6250,
+			expectedApplied: []basket.AppliedPromotion{
+				{Name: "pen-2x1", Products: map[string]int{"PEN": 3}, Discount: eur(500)},
+				{Name: "tshirt-bulk", Products: map[string]int{"TSHIRT": 3}, Discount: eur(1500)},
+			},
+		},
+		{
+			name: "Solve - Cheapest line promotion wins",
+			definitions: []Definition{
+				{Name: "mug-3x2", Type: TypeBuyNPayM, Products: []string{"MUG"}, Buy: 3, Pay: 2},
+				{Name: "mug-volume", Type: TypeTiered, Products: []string{"MUG"}, Tiers: []TierDefinition{
+					{MinQuantity: 3, DiscountPercent: 10},
+					{MinQuantity: 6, DiscountPercent: 40},
+				}},
+			},
+			products:      map[string]int{"MUG": 6},
+			expectedTotal: 2700,
+			expectedApplied: []basket.AppliedPromotion{
+				{Name: "mug-volume", Products: map[string]int{"MUG": 6}, Discount: eur(1800)},
+			},
+		},
+		{
+			name: "Solve - Bundle competes with line promotions",
+			definitions: append([]Definition{
+				{Name: "lana-pack", Type: TypeBundle, Products: []string{"PEN", "TSHIRT", "MUG"}, Price: price(2500)},
+			}, DefaultConfig().Promotions...),
+			products:      map[string]int{"PEN": 2, "TSHIRT": 1, "MUG": 2},
+			expectedTotal: 3750,
+			expectedApplied: []basket.AppliedPromotion{
+				{Name: "lana-pack", Products: map[string]int{"PEN": 1, "TSHIRT": 1, "MUG": 1}, Discount: eur(750)},
+			},
+		},
+		{
+			// Stacked with the 2x1 the bundle would cost 15€, alone it costs 20€.
+			name: "Solve - Not stackable bundle is not combined with line promotions",
+			definitions: append([]Definition{
+				{Name: "lana-pack", Type: TypeBundle, Products: []string{"PEN", "MUG"}, Price: price(1000), Stackable: &notStackable},
+			}, DefaultConfig().Promotions...),
+			products:      map[string]int{"PEN": 3, "MUG": 1},
+			expectedTotal: 1750,
+			expectedApplied: []basket.AppliedPromotion{
+				{Name: "pen-2x1", Products: map[string]int{"PEN": 3}, Discount: eur(500)},
+			},
+		},
+		{
+			name: "Solve - Exclusive promotion alone is cheaper",
+			definitions: append([]Definition{
+				{Name: "mug-half", Type: TypeBuyXOrMore, Products: []string{"MUG"}, MinQuantity: 1, DiscountPercent: 50, Exclusive: true},
+			}, DefaultConfig().Promotions...),
+			products:      map[string]int{"PEN": 2, "MUG": 4},
+			expectedTotal: 2500,
+			expectedApplied: []basket.AppliedPromotion{
+				{Name: "mug-half", Products: map[string]int{"MUG": 4}, Discount: eur(1500)},
+			},
+		},
+		{
+			name: "Solve - Exclusive promotion is not combined",
+			definitions: append([]Definition{
+				{Name: "mug-half", Type: TypeBuyXOrMore, Products: []string{"MUG"}, MinQuantity: 1, DiscountPercent: 50, Exclusive: true},
+			}, DefaultConfig().Promotions...),
+			products:      map[string]int{"PEN": 6, "MUG": 1},
+			expectedTotal: 2250,
+			expectedApplied: []basket.AppliedPromotion{
+				{Name: "pen-2x1", Products: map[string]int{"PEN": 6}, Discount: eur(1500)},
+			},
+		},
+		{
+			name: "Solve - Bundle applied up to a line promotion breakpoint",
+			definitions: []Definition{
+				{Name: "pen-mug", Type: TypeBundle, Products: []string{"PEN", "MUG"}, Price: price(1000)},
+				{Name: "pen-3x1", Type: TypeBuyNPayM, Products: []string{"PEN"}, Buy: 3, Pay: 1, MaxApplications: 300},
+			},
+			products:      map[string]int{"PEN": 1500, "MUG": 1000},
+			expectedTotal: 1050000,
+			expectedApplied: []basket.AppliedPromotion{
+				{Name: "pen-mug", Products: map[string]int{"PEN": 600, "MUG": 600}, Discount: eur(150000)},
+				{Name: "pen-3x1", Products: map[string]int{"PEN": 900}, Discount: eur(300000)},
+			},
+		},
+		{
+			name: "Solve - Millions of units",
+			definitions: append([]Definition{
+				{Name: "pen-mug", Type: TypeBundle, Products: []string{"PEN", "MUG"}, Price: price(1000)},
+			}, DefaultConfig().Promotions...),
+			products:      map[string]int{"PEN": 2000000, "MUG": 2000000},
+			expectedTotal: 2000000000,
+			expectedApplied: []basket.AppliedPromotion{
+				{Name: "pen-2x1", Products: map[string]int{"PEN": 2000000}, Discount: eur(500000000)},
+			},
+		},
+		{
+			name:          "Solve - Empty basket",
+			definitions:   DefaultConfig().Promotions,
+			products:      map[string]int{},
+			expectedTotal: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := Config{Promotions: tt.definitions}.Build()
+			require.NoError(t, err)
+			result := set.Solve(tt.products, catalog, basket.DefaultCurrency)
+			require.Equal(t, eur(tt.expectedTotal), result.Total)
+			require.Equal(t, tt.expectedApplied, result.Applied)
+		})
+	}
+}
+
+func TestSetSolveLines(t *testing.T) {
+	catalog := map[string]basket.Product{
+		"PEN":    {Code: "PEN", Name: "Lana Pen", Price: basket.NewMoney(500, basket.DefaultCurrency)},
+		"TSHIRT": {Code: "TSHIRT", Name: "Lana T-Shirt", Price: basket.NewMoney(2000, basket.DefaultCurrency)},
+		"MUG":    {Code: "MUG", Name: "Lana Coffee Mug", Price: basket.NewMoney(750, basket.DefaultCurrency)},
+	}
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+	bundlePrice := basket.NewMoney(2000, "")
+	set, err := Config{Promotions: append([]Definition{
+		{Name: "lana-pack", Type: TypeBundle, Products: []string{"PEN", "TSHIRT", "MUG"}, Price: &bundlePrice},
+	}, DefaultConfig().Promotions...)}.Build()
+	require.NoError(t, err)
+
+	// The bundle saves 12.50€ split as 2.88€ (MUG), 1.92€ (PEN) and the remaining 7.70€ (TSHIRT), the pens left go 2x1.
+	result := set.Solve(map[string]int{"PEN": 3, "TSHIRT": 1, "MUG": 1}, catalog, basket.DefaultCurrency)
+	require.Equal(t, eur(2500), result.Total)
+	require.Equal(t, []basket.LineItem{
+		{
+			Code: "MUG", Name: "Lana Coffee Mug", UnitPrice: eur(750), Quantity: 1,
+			Gross: eur(750), Discount: eur(288), Promotions: []string{"lana-pack"}, Net: eur(462),
+		},
+		{
+			Code: "PEN", Name: "Lana Pen", UnitPrice: eur(500), Quantity: 3,
+			Gross: eur(1500), Discount: eur(692), Promotions: []string{"lana-pack", "pen-2x1"}, Net: eur(808),
+		},
+		{
+			Code: "TSHIRT", Name: "Lana T-Shirt", UnitPrice: eur(2000), Quantity: 1,
+			Gross: eur(2000), Discount: eur(770), Promotions: []string{"lana-pack"}, Net: eur(1230),
+		},
+	}, result.Lines)
+
+	var net int64
+	for _, line := range result.Lines {
+		net += line.Net.Amount
+	}
+	require.Equal(t, result.Total.Amount, net)
+}
+
+func TestSetSolveCategories(t *testing.T) {
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+	catalog := map[string]basket.Product{
+		"PEN":    {Code: "PEN", Price: eur(500), Category: "office"},
+		"MUG":    {Code: "MUG", Price: eur(750), Category: "drinkware"},
+		"BOTTLE": {Code: "BOTTLE", Price: eur(1000), Category: "outdoor", Tags: []string{"drinkware"}},
+	}
+	set, err := Config{Promotions: []Definition{
+		{Name: "drinkware-20", Type: TypeBuyXOrMore, Categories: []string{"drinkware"}, MinQuantity: 1, DiscountPercent: 20},
+		{Name: "mug-2x1", Type: TypeBuyNPayM, Products: []string{"MUG"}, Buy: 2, Pay: 1},
+	}}.Build()
+	require.NoError(t, err)
+
+	result := set.Solve(map[string]int{"PEN": 1, "MUG": 1, "BOTTLE": 1}, catalog, basket.DefaultCurrency)
+	require.Equal(t, eur(1900), result.Total)
+	require.Equal(t, []basket.AppliedPromotion{
+		{Name: "drinkware-20", Products: map[string]int{"BOTTLE": 1}, Discount: eur(200)},
+		{Name: "drinkware-20", Products: map[string]int{"MUG": 1}, Discount: eur(150)},
+	}, result.Applied)
+
+	// The product promotion competes with the category one.
+	result = set.Solve(map[string]int{"MUG": 2}, catalog, basket.DefaultCurrency)
+	require.Equal(t, eur(750), result.Total)
+	require.Equal(t, "mug-2x1", result.Applied[0].Name)
+}
+
+func TestSetSolveCompetingBundles(t *testing.T) {
+	catalog := map[string]basket.Product{
+		"PEN":    {Code: "PEN", Price: basket.NewMoney(500, basket.DefaultCurrency)},
+		"TSHIRT": {Code: "TSHIRT", Price: basket.NewMoney(2000, basket.DefaultCurrency)},
+		"MUG":    {Code: "MUG", Price: basket.NewMoney(750, basket.DefaultCurrency)},
+	}
+	penMug, penTshirt := basket.NewMoney(1000, ""), basket.NewMoney(2000, "")
+	set, err := Config{Promotions: []Definition{
+		{Name: "pen-mug", Type: TypeBundle, Products: []string{"PEN", "MUG"}, Price: &penMug},
+		{Name: "pen-tshirt", Type: TypeBundle, Products: []string{"PEN", "TSHIRT"}, Price: &penTshirt, MaxApplications: 40},
+	}}.Build()
+	require.NoError(t, err)
+
+	// Both bundles take pens, so every number of applications of each one is a candidate.
+	cheapest := int64(-1)
+	for a := 0; a <= 100; a++ {
+		for b := 0; a+b <= 100 && b <= 40; b++ {
+			total := int64(a)*1000 + int64(b)*2000 + int64(100-a-b)*500 + int64(100-a)*750 + int64(100-b)*2000
+			if cheapest < 0 || total < cheapest {
+				cheapest = total
+			}
+		}
+	}
+	result := set.Solve(map[string]int{"PEN": 100, "MUG": 100, "TSHIRT": 100}, catalog, basket.DefaultCurrency)
+	require.Equal(t, basket.NewMoney(cheapest, basket.DefaultCurrency), result.Total)
+	require.Equal(t, int64(290000), cheapest)
+}
@@ -0,0 +1,46 @@
+package promotion
+
+import "github.com/mercadolibre/backend-challenge/internal/basket"
+
+// Tier is a volume breakpoint of a Tiered promotion.
+// Units get either Discount basis points off the list price or the fixed UnitPrice.
+type Tier struct {
+	MinQuantity int
+	Discount    int64
+	UnitPrice   *basket.Money
+}
+
+// Tiered is responsible for promotion methods.
+// The unit price drops at each breakpoint, the tier with the highest MinQuantity reached applies to every unit.
+// Tiers are sorted by MinQuantity.
+type Tiered struct {
+	Tiers []Tier
+}
+
+// Compute calculate the amount for tiered promotion.
+func (s *Tiered) Compute(product basket.Product, quantity int) basket.Money {
+	gross := product.Price.Mul(quantity)
+	var tier *Tier
+	for i := range s.Tiers {
+		if quantity >= s.Tiers[i].MinQuantity {
+			tier = &s.Tiers[i]
+		}
+	}
+	switch {
+	case tier == nil:
+		return gross
+	case tier.UnitPrice != nil:
+		return basket.NewMoney(tier.UnitPrice.Amount, product.Price.Currency).Mul(quantity)
+	default:
+		return gross.Sub(gross.Percent(tier.Discount))
+	}
+}
+
+// Steps changes at the MinQuantity of each tier.
+func (s *Tiered) Steps() ([]int, int) {
+	breakpoints := make([]int, 0, len(s.Tiers))
+	for _, tier := range s.Tiers {
+		breakpoints = append(breakpoints, tier.MinQuantity)
+	}
+	return breakpoints, 1
+}
@@ -0,0 +1,42 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTieredCompute(t *testing.T) {
+	mug := basket.Product{Code: "MUG", Name: "Lana Coffee Mug", Price: basket.NewMoney(750, basket.DefaultCurrency)}
+	unitPrice := basket.NewMoney(400, "")
+	percentTiers := Tiered{Tiers: []Tier{
+		{MinQuantity: 3, Discount: 1000},
+		{MinQuantity: 10, Discount: 2500},
+		{MinQuantity: 50, Discount: 4000},
+	}}
+	tests := []struct {
+		name     string
+		promo    Tiered
+		quantity int
+		expected int64
+	}{
+		{name: "Tiered - Below first tier", promo: percentTiers, quantity: 2, expected: 1500},
+		{name: "Tiered - First tier", promo: percentTiers, quantity: 3, expected: 2025},
+		{name: "Tiered - Second tier", promo: percentTiers, quantity: 10, expected: 5625},
+		{name: "Tiered - Third tier", promo: percentTiers, quantity: 50, expected: 22500},
+		{name: "Tiered - Discount rounded once per line", promo: percentTiers, quantity: 11, expected: 6187},
+		{
+			name:     "Tiered - Fixed unit price",
+			promo:    Tiered{Tiers: []Tier{{MinQuantity: 3, Discount: 1000}, {MinQuantity: 10, UnitPrice: &unitPrice}}},
+			quantity: 12,
+			expected: 4800,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, basket.NewMoney(tt.expected, basket.DefaultCurrency), tt.promo.Compute(mug, tt.quantity))
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package promotion
+
+import (
+	"sort"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+)
+
+// Units are the units of a product in a basket.
+type Units struct {
+	Product  basket.Product
+	Quantity int
+}
+
+// GroupPromotion is implemented by the line promotions that price the variants of a product together,
+// so the units of every size or colour count to reach the promotion of their parent.
+type GroupPromotion interface {
+	ComputeGroup(lines []Units) basket.Money
+}
+
+// ComputeGroup prices the variants with the discount of the total quantity, rounded per variant.
+func (s *BuyXOrMore) ComputeGroup(lines []Units) basket.Money {
+	quantity := totalUnits(lines)
+	total := basket.NewMoney(0, lines[0].Product.Price.Currency)
+	for _, line := range lines {
+		gross := line.Product.Price.Mul(line.Quantity)
+		if quantity >= s.MinQuantity {
+			gross = gross.Sub(gross.Percent(s.Discount))
+		}
+		total = total.Add(gross)
+	}
+	return total
+}
+
+// ComputeGroup prices the variants with the tier of the total quantity, rounded per variant.
+func (s *Tiered) ComputeGroup(lines []Units) basket.Money {
+	quantity := totalUnits(lines)
+	var tier *Tier
+	for i := range s.Tiers {
+		if quantity >= s.Tiers[i].MinQuantity {
+			tier = &s.Tiers[i]
+		}
+	}
+	total := basket.NewMoney(0, lines[0].Product.Price.Currency)
+	for _, line := range lines {
+		gross := line.Product.Price.Mul(line.Quantity)
+		switch {
+		case tier == nil:
+		case tier.UnitPrice != nil:
+			gross = basket.NewMoney(tier.UnitPrice.Amount, line.Product.Price.Currency).Mul(line.Quantity)
+		default:
+			gross = gross.Sub(gross.Percent(tier.Discount))
+		}
+		total = total.Add(gross)
+	}
+	return total
+}
+
+// ComputeGroup groups the units of every variant, the free units are the cheapest ones.
+func (s *BuyNPayM) ComputeGroup(lines []Units) basket.Money {
+	groups := totalUnits(lines) / s.Buy
+	if s.MaxApplications > 0 && groups > s.MaxApplications {
+		groups = s.MaxApplications
+	}
+	free := groups * (s.Buy - s.Pay)
+
+	sorted := append([]Units{}, lines...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Product.Price.Amount < sorted[j].Product.Price.Amount
+	})
+	total := basket.NewMoney(0, lines[0].Product.Price.Currency)
+	for _, line := range sorted {
+		charged := line.Quantity
+		if free > 0 {
+			freeUnits := free
+			if freeUnits > charged {
+				freeUnits = charged
+			}
+			charged -= freeUnits
+			free -= freeUnits
+		}
+		total = total.Add(line.Product.Price.Mul(charged))
+	}
+	return total
+}
+
+// computeLines prices the lines of a product and its variants with the promotion. The line promotions
+// that do not implement GroupPromotion price each variant on its own.
+func computeLines(promotion Promotion, lines []Units) basket.Money {
+	if len(lines) == 1 {
+		return promotion.Compute(lines[0].Product, lines[0].Quantity)
+	}
+	if group, ok := promotion.(GroupPromotion); ok {
+		return group.ComputeGroup(lines)
+	}
+	total := basket.NewMoney(0, lines[0].Product.Price.Currency)
+	for _, line := range lines {
+		total = total.Add(promotion.Compute(line.Product, line.Quantity))
+	}
+	return total
+}
+
+func totalUnits(lines []Units) int {
+	quantity := 0
+	for _, line := range lines {
+		quantity += line.Quantity
+	}
+	return quantity
+}
+
+// lineGroups groups the codes of the products by the code their line promotions are kept under,
+// the parent for the variants. Products without units are left out and the codes are sorted.
+func lineGroups(products map[string]int, catalog map[string]basket.Product) map[string][]string {
+	groups := make(map[string][]string)
+	for _, code := range sortedCodes(products) {
+		if products[code] <= 0 {
+			continue
+		}
+		group := code
+		if parent := catalog[code].Parent; parent != "" {
+			group = parent
+		}
+		groups[group] = append(groups[group], code)
+	}
+	return groups
+}
+
+func sortedGroups(groups map[string][]string) []string {
+	codes := make([]string, 0, len(groups))
+	for code := range groups {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
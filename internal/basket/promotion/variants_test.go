@@ -0,0 +1,82 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/mercadolibre/backend-challenge/internal/basket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeGroup(t *testing.T) {
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+	small := basket.Product{Code: "TSHIRT-S", Parent: "TSHIRT", Price: eur(2000)}
+	large := basket.Product{Code: "TSHIRT-XL", Parent: "TSHIRT", Price: eur(2200)}
+	unitPrice := eur(1500)
+
+	tests := []struct {
+		name      string
+		promotion Promotion
+		lines     []Units
+		expected  int64
+	}{
+		{
+			name:      "BuyXOrMore - Reached across variants",
+			promotion: &BuyXOrMore{MinQuantity: 3, Discount: 2500},
+			lines:     []Units{{Product: small, Quantity: 2}, {Product: large, Quantity: 1}},
+			expected:  4650,
+		},
+		{
+			name:      "BuyXOrMore - Not reached",
+			promotion: &BuyXOrMore{MinQuantity: 3, Discount: 2500},
+			lines:     []Units{{Product: small, Quantity: 1}, {Product: large, Quantity: 1}},
+			expected:  4200,
+		},
+		{
+			name:      "BuyNPayM - Cheapest units are free",
+			promotion: &BuyNPayM{Buy: 3, Pay: 2},
+			lines:     []Units{{Product: large, Quantity: 2}, {Product: small, Quantity: 1}},
+			expected:  4400,
+		},
+		{
+			name:      "Tiered - Unit price for every variant",
+			promotion: &Tiered{Tiers: []Tier{{MinQuantity: 2, UnitPrice: &unitPrice}}},
+			lines:     []Units{{Product: small, Quantity: 1}, {Product: large, Quantity: 1}},
+			expected:  3000,
+		},
+		{
+			name:      "Single line - Compute",
+			promotion: &BuyXOrMore{MinQuantity: 3, Discount: 2500},
+			lines:     []Units{{Product: small, Quantity: 3}},
+			expected:  4500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, eur(tt.expected), computeLines(tt.promotion, tt.lines))
+		})
+	}
+}
+
+func TestSetSolveVariants(t *testing.T) {
+	eur := func(amount int64) basket.Money { return basket.NewMoney(amount, basket.DefaultCurrency) }
+	catalog := map[string]basket.Product{
+		"PEN":       {Code: "PEN", Price: eur(500)},
+		"TSHIRT":    {Code: "TSHIRT", Price: eur(2000)},
+		"TSHIRT-S":  {Code: "TSHIRT-S", Parent: "TSHIRT", Price: eur(2000)},
+		"TSHIRT-M":  {Code: "TSHIRT-M", Parent: "TSHIRT", Price: eur(2000)},
+		"TSHIRT-XL": {Code: "TSHIRT-XL", Parent: "TSHIRT", Price: eur(2200)},
+	}
+	set, err := DefaultConfig().Build()
+	require.NoError(t, err)
+
+	result := set.Solve(map[string]int{"TSHIRT-S": 1, "TSHIRT-M": 1, "TSHIRT-XL": 1, "PEN": 2}, catalog, basket.DefaultCurrency)
+	require.Equal(t, eur(5150), result.Total)
+	require.Equal(t, []basket.AppliedPromotion{
+		{Name: "pen-2x1", Products: map[string]int{"PEN": 2}, Discount: eur(500)},
+		{Name: "tshirt-bulk", Products: map[string]int{"TSHIRT-S": 1, "TSHIRT-M": 1, "TSHIRT-XL": 1}, Discount: eur(1550)},
+	}, result.Applied)
+	require.Len(t, result.Lines, 4)
+	require.Equal(t, eur(550), result.Lines[3].Discount)
+	require.Equal(t, []string{"tshirt-bulk"}, result.Lines[3].Promotions)
+}
@@ -6,6 +6,11 @@ import "github.com/mercadolibre/backend-challenge/internal/basket"
 type WithoutPromo struct{}
 
 // Compute calculate the amount for withoutPromo promotion.
-func (s *WithoutPromo) Compute(product basket.Product, quantity int) float64 {
-	return product.Price * float64(quantity)
+func (s *WithoutPromo) Compute(product basket.Product, quantity int) basket.Money {
+	return product.Price.Mul(quantity)
+}
+
+// Steps never changes, every unit costs the same.
+func (s *WithoutPromo) Steps() ([]int, int) {
+	return nil, 1
 }